@@ -14,11 +14,13 @@
 package api
 
 import (
+	"bytes"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/pingcap/pd/server"
+	log "github.com/sirupsen/logrus"
 	"github.com/unrolled/render"
 )
 
@@ -51,3 +53,19 @@ func (h *adminHandler) HandleDropCacheRegion(w http.ResponseWriter, r *http.Requ
 	cluster.DropCacheRegion(regionID)
 	h.rd.JSON(w, http.StatusOK, nil)
 }
+
+// HandleHotRegionBundle returns the support bundle of the hot region
+// scheduler, a gzipped JSON document of its internal state.
+func (h *adminHandler) HandleHotRegionBundle(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := h.svr.GetHandler().WriteHotSupportBundle(&buf); err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="hot-region-bundle.json.gz"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		log.Errorf("failed to write the hot region bundle: %v", err)
+	}
+}
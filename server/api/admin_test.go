@@ -14,6 +14,8 @@
 package api
 
 import (
+	"compress/gzip"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -78,3 +80,28 @@ func (s *testAdminSuite) TestDropRegion(c *C) {
 	c.Assert(region.GetRegionEpoch().ConfVer, Equals, uint64(50))
 	c.Assert(region.GetRegionEpoch().Version, Equals, uint64(50))
 }
+
+func (s *testAdminSuite) TestHotRegionBundle(c *C) {
+	handler := s.svr.GetHandler()
+	sches, err := handler.GetSchedulers()
+	c.Assert(err, IsNil)
+	running := false
+	for _, name := range sches {
+		running = running || name == "balance-hot-region-scheduler"
+	}
+	if !running {
+		c.Assert(handler.AddScheduler("hot-region"), IsNil)
+	}
+
+	res, err := http.Get(s.urlPrefix + "/admin/hot-region/bundle")
+	c.Assert(err, IsNil)
+	defer res.Body.Close()
+	c.Assert(res.StatusCode, Equals, http.StatusOK)
+	c.Assert(res.Header.Get("Content-Type"), Equals, "application/gzip")
+	gz, err := gzip.NewReader(res.Body)
+	c.Assert(err, IsNil)
+	var bundle map[string]interface{}
+	c.Assert(json.NewDecoder(gz).Decode(&bundle), IsNil)
+	c.Assert(bundle["version"], Equals, float64(1))
+	c.Assert(bundle["config"], NotNil)
+}
@@ -17,10 +17,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/schedule"
 	"github.com/pkg/errors"
 	"github.com/unrolled/render"
 )
@@ -62,6 +64,7 @@ const (
 	tikvCap90
 	tikvLostPeers
 	tikvLostPeersLongTime
+	scheduleFilterRejected
 )
 
 var (
@@ -76,6 +79,7 @@ var (
 		tikvCap90:                   {modTiKV, levelMajor, "some TiKV storage used more than 90%.", "please add TiKV node."},
 		tikvLostPeers:               {modTiKV, levelWarning, "some TiKV lost connect.", "please check network."},
 		tikvLostPeersLongTime:       {modTiKV, levelMajor, "some TiKV lost connect more than 1h.", "please check network."},
+		scheduleFilterRejected:      {modSchedule, levelNormal, "some target stores are rejected by the filters of schedulers.", "please check the store status if a store is always rejected."},
 	}
 )
 
@@ -164,11 +168,24 @@ func (d *diagnoseHandler) tikvDiagnose(rdd *[]*Recommendation) error {
 	return nil
 }
 
+func (d *diagnoseHandler) scheduleDiagnose(rdd *[]*Recommendation) {
+	rejections := schedule.GetFilterRejections()
+	names := make([]string, 0, len(rejections))
+	for name := range rejections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		*rdd = append(*rdd, diagnosePD(scheduleFilterRejected, fmt.Sprintf("scheduler %s: %s", name, rejections[name]), ""))
+	}
+}
+
 func (d *diagnoseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	rdd := []*Recommendation{}
 	if err := d.membersDiagnose(&rdd); err != nil {
 		d.rd.JSON(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	d.scheduleDiagnose(&rdd)
 	d.rd.JSON(w, http.StatusOK, rdd)
 }
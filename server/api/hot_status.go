@@ -15,8 +15,11 @@ package api
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedulers"
 	"github.com/unrolled/render"
 )
 
@@ -47,6 +50,18 @@ func (h *hotStatusHandler) GetHotReadRegions(w http.ResponseWriter, r *http.Requ
 	h.rd.JSON(w, http.StatusOK, h.Handler.GetHotReadRegions())
 }
 
+type hotRegionsStatus struct {
+	Read  *core.StoreHotRegionInfos `json:"read"`
+	Write *core.StoreHotRegionInfos `json:"write"`
+}
+
+// GetHotRegions returns both the hot read and write regions in a consistent
+// snapshot.
+func (h *hotStatusHandler) GetHotRegions(w http.ResponseWriter, r *http.Request) {
+	read, write := h.Handler.GetHotRegions()
+	h.rd.JSON(w, http.StatusOK, hotRegionsStatus{Read: read, Write: write})
+}
+
 func (h *hotStatusHandler) GetHotStores(w http.ResponseWriter, r *http.Request) {
 	bytesWriteStats := h.GetHotBytesWriteStores()
 	bytesReadStats := h.GetHotBytesReadStores()
@@ -61,3 +76,43 @@ func (h *hotStatusHandler) GetHotStores(w http.ResponseWriter, r *http.Request)
 	}
 	h.rd.JSON(w, http.StatusOK, stats)
 }
+
+const defaultHotReportDays = 7
+
+// GetDailyReports returns the hot region moves per day and per store.
+func (h *hotStatusHandler) GetDailyReports(w http.ResponseWriter, r *http.Request) {
+	days := defaultHotReportDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil {
+			h.rd.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if days <= 0 {
+			h.rd.JSON(w, http.StatusBadRequest, "days should be positive")
+			return
+		}
+	}
+	reports, err := h.GetHotRegionDailyReport(days)
+	if err != nil {
+		h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, reports)
+}
+
+// TraceDecision returns the decision trace of the hot region scheduler on the
+// posted synthetic snapshot, the real cluster is not touched.
+func (h *hotStatusHandler) TraceDecision(w http.ResponseWriter, r *http.Request) {
+	var snapshot schedulers.HotRegionSnapshot
+	if err := readJSONRespondError(h.rd, w, r.Body, &snapshot); err != nil {
+		return
+	}
+	trace, err := schedulers.TraceHotRegionDecision(&snapshot)
+	if err != nil {
+		h.rd.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.rd.JSON(w, http.StatusOK, trace)
+}
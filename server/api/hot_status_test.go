@@ -14,12 +14,14 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/pd/server"
-	_ "github.com/pingcap/pd/server/schedulers"
+	"github.com/pingcap/pd/server/schedulers"
 )
 
 var _ = Suite(&testHotStatusSuite{})
@@ -51,3 +53,52 @@ func (s testHotStatusSuite) TestGetHotStore(c *C) {
 	err = readJSON(resp.Body, &stat)
 	c.Assert(err, IsNil)
 }
+
+func (s testHotStatusSuite) TestTraceDecision(c *C) {
+	snapshot := schedulers.HotRegionSnapshot{
+		Kind: "leader",
+		Stores: []schedulers.HotSnapshotStore{
+			{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4},
+		},
+		Regions: []schedulers.HotSnapshotRegion{
+			{ID: 1, Leader: 1, Followers: []uint64{2, 3}, FlowBytes: 1024, HotDegree: 3},
+			{ID: 2, Leader: 1, Followers: []uint64{2, 3}, FlowBytes: 1024, HotDegree: 3},
+			{ID: 3, Leader: 2, Followers: []uint64{1, 3}, FlowBytes: 1024, HotDegree: 3},
+		},
+	}
+	data, err := json.Marshal(snapshot)
+	c.Assert(err, IsNil)
+	resp, err := server.DialClient.Post(s.urlPrefix+"/trace", "application/json", bytes.NewBuffer(data))
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	trace := schedulers.HotDecisionTrace{}
+	c.Assert(readJSON(resp.Body, &trace), IsNil)
+
+	// Store 1 holds the most hot leaders, store 2 holds a hot leader too so
+	// only store 3 is cold enough.
+	c.Assert(trace.Stats[1].RegionsCount, Equals, 2)
+	c.Assert(trace.Stats[2].RegionsCount, Equals, 1)
+	c.Assert(trace.SourceStoreID, Equals, uint64(1))
+	c.Assert(trace.RegionID == 1 || trace.RegionID == 2, IsTrue)
+	c.Assert(trace.DestStoreID, Equals, uint64(3))
+	c.Assert(trace.Regions, HasLen, 1)
+	c.Assert(trace.Regions[0].RegionID, Equals, trace.RegionID)
+	for _, candidate := range trace.Regions[0].Candidates {
+		switch candidate.StoreID {
+		case 2:
+			c.Assert(candidate.Result, Equals, "insufficient-margin")
+		case 3:
+			c.Assert(candidate.Result, Equals, "no-hot-region")
+		default:
+			c.Fatalf("unexpected candidate %d", candidate.StoreID)
+		}
+	}
+
+	snapshot.Kind = "unknown"
+	data, err = json.Marshal(snapshot)
+	c.Assert(err, IsNil)
+	resp, err = server.DialClient.Post(s.urlPrefix+"/trace", "application/json", bytes.NewBuffer(data))
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+}
@@ -41,7 +41,19 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	schedulerHandler := newSchedulerHandler(handler, rd)
 	router.HandleFunc("/api/v1/schedulers", schedulerHandler.List).Methods("GET")
 	router.HandleFunc("/api/v1/schedulers", schedulerHandler.Post).Methods("POST")
+	router.HandleFunc("/api/v1/schedulers/hot-region", schedulerHandler.PatchHotRegion).Methods("PATCH")
 	router.HandleFunc("/api/v1/schedulers/{name}", schedulerHandler.Delete).Methods("DELETE")
+	router.HandleFunc("/api/v1/schedulers/hot-region/history", schedulerHandler.HotRegionHistory).Methods("GET")
+	router.HandleFunc("/api/v1/schedulers/hot-region/changes", schedulerHandler.HotRegionChanges).Methods("GET")
+	router.HandleFunc("/api/v1/schedulers/hot-region/exclusion", schedulerHandler.GetHotStoreExclusion).Methods("GET")
+	router.HandleFunc("/api/v1/schedulers/hot-region/exclusion", schedulerHandler.SetHotStoreExclusion).Methods("POST")
+	router.HandleFunc("/api/v1/schedulers/hot-region/blacklist/{store_id}", schedulerHandler.AddHotTargetBlacklist).Methods("POST")
+	router.HandleFunc("/api/v1/schedulers/hot-region/blacklist/{store_id}", schedulerHandler.RemoveHotTargetBlacklist).Methods("DELETE")
+	router.HandleFunc("/api/v1/schedule/rounds", schedulerHandler.Rounds).Methods("GET")
+
+	schedulerConfigPrefix := path.Join(prefix, "/api/v1/scheduler-config")
+	schedulerConfigHandler := newSchedulerConfigHandler(handler, rd, schedulerConfigPrefix)
+	router.PathPrefix("/api/v1/scheduler-config/").Handler(schedulerConfigHandler)
 
 	router.Handle("/api/v1/cluster", newClusterHandler(svr, rd)).Methods("GET")
 	router.HandleFunc("/api/v1/cluster/status", newClusterHandler(svr, rd).GetClusterStatus).Methods("GET")
@@ -74,9 +86,12 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 	router.HandleFunc("/api/v1/labels/stores", labelsHandler.GetStores).Methods("GET")
 
 	hotStatusHandler := newHotStatusHandler(handler, rd)
+	router.HandleFunc("/api/v1/hotspot/regions", hotStatusHandler.GetHotRegions).Methods("GET")
 	router.HandleFunc("/api/v1/hotspot/regions/write", hotStatusHandler.GetHotWriteRegions).Methods("GET")
 	router.HandleFunc("/api/v1/hotspot/regions/read", hotStatusHandler.GetHotReadRegions).Methods("GET")
 	router.HandleFunc("/api/v1/hotspot/stores", hotStatusHandler.GetHotStores).Methods("GET")
+	router.HandleFunc("/api/v1/hotspot/trace", hotStatusHandler.TraceDecision).Methods("POST")
+	router.HandleFunc("/api/v1/hotspot/reports/daily", hotStatusHandler.GetDailyReports).Methods("GET")
 
 	regionHandler := newRegionHandler(svr, rd)
 	router.HandleFunc("/api/v1/region/id/{id}", regionHandler.GetRegionByID).Methods("GET")
@@ -124,6 +139,7 @@ func createRouter(prefix string, svr *server.Server) *mux.Router {
 
 	adminHandler := newAdminHandler(svr, rd)
 	router.HandleFunc("/api/v1/admin/cache/region/{id}", adminHandler.HandleDropCacheRegion).Methods("DELETE")
+	router.HandleFunc("/api/v1/admin/hot-region/bundle", adminHandler.HandleHotRegionBundle).Methods("GET")
 
 	logHanler := newlogHandler(svr, rd)
 	router.HandleFunc("/api/v1/admin/log", logHanler.Handle).Methods("POST")
@@ -14,10 +14,14 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pkg/errors"
 	"github.com/unrolled/render"
 )
 
@@ -42,6 +46,152 @@ func (h *schedulerHandler) List(w http.ResponseWriter, r *http.Request) {
 	h.r.JSON(w, http.StatusOK, schedulers)
 }
 
+func (h *schedulerHandler) Rounds(w http.ResponseWriter, r *http.Request) {
+	rounds, err := h.GetScheduleRounds()
+	if err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.r.JSON(w, http.StatusOK, rounds)
+}
+
+func (h *schedulerHandler) HotRegionHistory(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	history, err := h.GetHotRegionHistory(limit)
+	if err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.r.JSON(w, http.StatusOK, history)
+}
+
+func (h *schedulerHandler) HotRegionChanges(w http.ResponseWriter, r *http.Request) {
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	source := r.URL.Query().Get("source")
+	if source != "" && source != core.HotChangeSourceUser && source != core.HotChangeSourceInternal {
+		h.r.JSON(w, http.StatusBadRequest, fmt.Sprintf("unknown source %q", source))
+		return
+	}
+	if source == "" {
+		changes, err := h.GetHotSchedulerChanges(limit)
+		if err != nil {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		h.r.JSON(w, http.StatusOK, changes)
+		return
+	}
+	all, err := h.GetHotSchedulerChanges(0)
+	if err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	changes := make([]core.HotSchedulerChange, 0, len(all))
+	for _, change := range all {
+		if change.Source == source && (limit <= 0 || len(changes) < limit) {
+			changes = append(changes, change)
+		}
+	}
+	h.r.JSON(w, http.StatusOK, changes)
+}
+
+func (h *schedulerHandler) GetHotStoreExclusion(w http.ResponseWriter, r *http.Request) {
+	cfg, err := h.Handler.GetHotStoreExclusion()
+	if err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.r.JSON(w, http.StatusOK, cfg)
+}
+
+func (h *schedulerHandler) SetHotStoreExclusion(w http.ResponseWriter, r *http.Request) {
+	var cfg core.HotStoreExclusion
+	if err := readJSONRespondError(h.r, w, r.Body, &cfg); err != nil {
+		return
+	}
+	if err := h.Handler.SetHotStoreExclusion(cfg); err != nil {
+		if errors.Cause(err) == server.ErrNotBootstrapped {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		} else {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+	h.r.JSON(w, http.StatusOK, nil)
+}
+
+func (h *schedulerHandler) AddHotTargetBlacklist(w http.ResponseWriter, r *http.Request) {
+	h.setHotTargetBlacklist(w, r, true)
+}
+
+func (h *schedulerHandler) RemoveHotTargetBlacklist(w http.ResponseWriter, r *http.Request) {
+	h.setHotTargetBlacklist(w, r, false)
+}
+
+func (h *schedulerHandler) setHotTargetBlacklist(w http.ResponseWriter, r *http.Request, blacklisted bool) {
+	storeID, err := strconv.ParseUint(mux.Vars(r)["store_id"], 10, 64)
+	if err != nil {
+		h.r.JSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if blacklisted {
+		err = h.AddHotTargetBlacklist(storeID)
+	} else {
+		err = h.RemoveHotTargetBlacklist(storeID)
+	}
+	if err != nil {
+		h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.r.JSON(w, http.StatusOK, nil)
+}
+
+func (h *schedulerHandler) PatchHotRegion(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Types  []string `json:"types"`
+		DryRun *bool    `json:"dry_run"`
+	}
+	if err := readJSONRespondError(h.r, w, r.Body, &input); err != nil {
+		return
+	}
+	if input.Types == nil && input.DryRun == nil {
+		h.r.JSON(w, http.StatusBadRequest, "nothing to change")
+		return
+	}
+	var err error
+	if input.Types != nil {
+		err = h.SetHotBalanceTypes(input.Types)
+	}
+	if err == nil && input.DryRun != nil {
+		err = h.SetHotDryRun(*input.DryRun)
+	}
+	if err != nil {
+		if errors.Cause(err) == server.ErrNotBootstrapped {
+			h.r.JSON(w, http.StatusInternalServerError, err.Error())
+		} else {
+			h.r.JSON(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+	h.r.JSON(w, http.StatusOK, nil)
+}
+
 func (h *schedulerHandler) Post(w http.ResponseWriter, r *http.Request) {
 	var input map[string]interface{}
 	if err := readJSONRespondError(h.r, w, r.Body, &input); err != nil {
@@ -0,0 +1,57 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/pingcap/pd/server"
+	"github.com/pkg/errors"
+	"github.com/unrolled/render"
+)
+
+// schedulerConfigHandler redirects requests to the running scheduler named by
+// the first element of the path if the scheduler implements http.Handler.
+type schedulerConfigHandler struct {
+	*server.Handler
+	rd     *render.Render
+	prefix string
+}
+
+func newSchedulerConfigHandler(handler *server.Handler, rd *render.Render, prefix string) *schedulerConfigHandler {
+	return &schedulerConfigHandler{
+		Handler: handler,
+		rd:      rd,
+		prefix:  prefix,
+	}
+}
+
+func (h *schedulerConfigHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.SplitN(strings.TrimPrefix(r.URL.Path, h.prefix+"/"), "/", 2)[0]
+	handler, err := h.GetSchedulerHandler(name)
+	if err != nil {
+		switch errors.Cause(err) {
+		case server.ErrNotBootstrapped:
+			h.rd.JSON(w, http.StatusInternalServerError, err.Error())
+		case server.ErrSchedulerNotSupportHTTP:
+			h.rd.JSON(w, http.StatusNotAcceptable, err.Error())
+		default:
+			h.rd.JSON(w, http.StatusNotFound, err.Error())
+		}
+		return
+	}
+	http.StripPrefix(path.Join(h.prefix, name), handler).ServeHTTP(w, r)
+}
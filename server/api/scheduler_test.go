@@ -16,10 +16,12 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server"
+	"github.com/pingcap/pd/server/core"
 	_ "github.com/pingcap/pd/server/schedulers"
 )
 
@@ -100,3 +102,59 @@ func (s *testScheduleSuite) testAddAndRemoveScheduler(name, createdName string,
 	err = doDelete(deleteURL)
 	c.Assert(err, IsNil)
 }
+
+func (s *testScheduleSuite) TestSchedulerConfig(c *C) {
+	handler := s.svr.GetHandler()
+	sches, err := handler.GetSchedulers()
+	c.Assert(err, IsNil)
+	running := false
+	for _, name := range sches {
+		running = running || name == "balance-hot-region-scheduler"
+	}
+	if !running {
+		c.Assert(handler.AddScheduler("hot-region"), IsNil)
+	}
+	urlPrefix := fmt.Sprintf("%s%s/api/v1/scheduler-config", s.svr.GetAddr(), apiPrefix)
+	hotURL := urlPrefix + "/balance-hot-region-scheduler"
+
+	var state struct {
+		Config map[string]interface{} `json:"config"`
+	}
+	c.Assert(readJSONWithURL(hotURL, &state), IsNil)
+	c.Assert(state.Config["schedule-factor"], Equals, 0.9)
+	c.Assert(postJSON(hotURL, []byte(`{"schedule-factor":0.85,"model-mode":"off"}`)), IsNil)
+	c.Assert(readJSONWithURL(hotURL, &state), IsNil)
+	c.Assert(state.Config["schedule-factor"], Equals, 0.85)
+	c.Assert(state.Config["model-mode"], Equals, "off")
+	// The changes made through the API are told apart from the internal ones.
+	var changes []core.HotSchedulerChange
+	c.Assert(readJSONWithURL(s.urlPrefix+"/hot-region/changes?source=user", &changes), IsNil)
+	c.Assert(changes, Not(HasLen), 0)
+	for _, change := range changes {
+		c.Assert(change.Source, Equals, core.HotChangeSourceUser)
+	}
+	c.Assert(changes[0].Name, Equals, "schedule-factor")
+	c.Assert(readJSONWithURL(s.urlPrefix+"/hot-region/changes?source=user&limit=1", &changes), IsNil)
+	c.Assert(changes, HasLen, 1)
+	resp, err := http.Get(s.urlPrefix + "/hot-region/changes?source=unknown")
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
+	c.Assert(postJSON(hotURL, []byte(`{"limit":2,"model-url":""}`)), IsNil)
+	c.Assert(readJSONWithURL(hotURL, &state), IsNil)
+	c.Assert(state.Config["limit"], Equals, 2.0)
+	// The read only and invalid fields are rejected.
+	c.Assert(postJSON(hotURL, []byte(`{"read-limit":3}`)), NotNil)
+	c.Assert(postJSON(hotURL, []byte(`{"limit-factor":2}`)), NotNil)
+
+	resp, err = http.Get(urlPrefix + "/unknown-scheduler")
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusNotFound)
+	c.Assert(postJSON(s.urlPrefix, []byte(`{"name":"shuffle-leader-scheduler"}`)), IsNil)
+	resp, err = http.Get(urlPrefix + "/shuffle-leader-scheduler")
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusNotAcceptable)
+	c.Assert(doDelete(s.urlPrefix+"/shuffle-leader-scheduler"), IsNil)
+}
@@ -269,6 +269,11 @@ func (c *clusterInfo) GetRegion(regionID uint64) *core.RegionInfo {
 	return c.core.GetRegion(regionID)
 }
 
+// GetClusterVersion returns the version of the cluster.
+func (c *clusterInfo) GetClusterVersion() semver.Version {
+	return c.opt.loadClusterVersion()
+}
+
 // IsRegionHot checks if a region is in hot state.
 func (c *clusterInfo) IsRegionHot(id uint64) bool {
 	c.RLock()
@@ -676,6 +681,18 @@ func (c *clusterInfo) GetHotRegionLowThreshold() int {
 	return c.opt.GetHotRegionLowThreshold()
 }
 
+func (c *clusterInfo) GetHotRegionModelURL() string {
+	return c.opt.GetHotRegionModelURL()
+}
+
+func (c *clusterInfo) IsHotRegionModelDisabled() bool {
+	return c.opt.IsHotRegionModelDisabled()
+}
+
+func (c *clusterInfo) GetHotRegionMinFlowBytes() uint64 {
+	return c.opt.GetHotRegionMinFlowBytes()
+}
+
 func (c *clusterInfo) IsRaftLearnerEnabled() bool {
 	if !c.IsFeatureSupported(RaftLearner) {
 		return false
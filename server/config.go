@@ -465,6 +465,17 @@ type ScheduleConfig struct {
 	// DisableNamespaceRelocation is the option to prevent namespace checker
 	// from moving replica to the target namespace.
 	DisableNamespaceRelocation bool `toml:"disable-namespace-relocation" json:"disable-namespace-relocation,string"`
+	// HotRegionModelURL is the URL of the model service which the hot region
+	// scheduler reports its decisions to, empty means no model service.
+	HotRegionModelURL string `toml:"hot-region-model-url,omitempty" json:"hot-region-model-url"`
+	// DisableHotRegionModel is the kill switch of the model service, no
+	// request is sent to it while it is set, whatever the hot region
+	// schedulers are configured with.
+	DisableHotRegionModel bool `toml:"disable-hot-region-model" json:"disable-hot-region-model,string"`
+	// HotRegionMinFlowBytes is the flow bytes per second below which a hot
+	// region is not worth moving by the hot region scheduler. 0 means no
+	// region is filtered.
+	HotRegionMinFlowBytes uint64 `toml:"hot-region-min-flow-bytes,omitempty" json:"hot-region-min-flow-bytes"`
 
 	// Schedulers support for loding customized schedulers
 	Schedulers SchedulerConfigs `toml:"schedulers,omitempty" json:"schedulers-v2"` // json v2 is for the sake of compatible upgrade
@@ -495,6 +506,9 @@ func (c *ScheduleConfig) clone() *ScheduleConfig {
 		DisableRemoveExtraReplica:    c.DisableRemoveExtraReplica,
 		DisableLocationReplacement:   c.DisableLocationReplacement,
 		DisableNamespaceRelocation:   c.DisableNamespaceRelocation,
+		HotRegionModelURL:            c.HotRegionModelURL,
+		DisableHotRegionModel:        c.DisableHotRegionModel,
+		HotRegionMinFlowBytes:        c.HotRegionMinFlowBytes,
 		Schedulers:                   schedulers,
 	}
 }
@@ -515,6 +529,8 @@ const (
 	defaultTolerantSizeRatio    = 5
 	defaultLowSpaceRatio        = 0.8
 	defaultHighSpaceRatio       = 0.6
+	// defaultHotRegionMinFlowBytes is 1 MB/s.
+	defaultHotRegionMinFlowBytes = 1024 * 1024
 )
 
 func (c *ScheduleConfig) adjust(meta *configMetaData) error {
@@ -550,6 +566,9 @@ func (c *ScheduleConfig) adjust(meta *configMetaData) error {
 	}
 	adjustFloat64(&c.LowSpaceRatio, defaultLowSpaceRatio)
 	adjustFloat64(&c.HighSpaceRatio, defaultHighSpaceRatio)
+	if !meta.IsDefined("hot-region-min-flow-bytes") {
+		adjustUint64(&c.HotRegionMinFlowBytes, defaultHotRegionMinFlowBytes)
+	}
 	adjustSchedulers(&c.Schedulers, defaultSchedulers)
 
 	return c.validate()
@@ -568,6 +587,11 @@ func (c *ScheduleConfig) validate() error {
 	if c.LowSpaceRatio <= c.HighSpaceRatio {
 		return errors.New("low-space-ratio should be larger than high-space-ratio")
 	}
+	if c.HotRegionModelURL != "" {
+		if u, err := url.Parse(c.HotRegionModelURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.Errorf("hot-region-model-url %q should be an absolute URL", c.HotRegionModelURL)
+		}
+	}
 	return nil
 }
 
@@ -64,6 +64,30 @@ func (s *testConfigSuite) TestReloadConfig(c *C) {
 	c.Assert(newOpt.GetMaxSnapshotCount(), Equals, uint64(10))
 }
 
+func (s *testConfigSuite) TestReloadSchedulerArgs(c *C) {
+	_, opt := newTestScheduleConfig()
+	kv := core.NewKV(core.NewMemoryKV())
+	c.Assert(opt.SetSchedulerArgs("balance-hot-region-scheduler", "schedule-factor=0.85"), IsNil)
+	c.Assert(opt.SetSchedulerArgs("balance-hot-region-scheduler", "schedule-factor=0.8", "limit-factor=0.5"), IsNil)
+	c.Assert(opt.SetSchedulerArgs("unknown-scheduler", "limit-factor=0.5"), NotNil)
+	opt.persist(kv)
+
+	// The default entry is disabled and the scheduler is restored with the
+	// arguments instead.
+	_, newOpt := newTestScheduleConfig()
+	newOpt.reload(kv)
+	schedulers := newOpt.GetSchedulers()
+	c.Assert(schedulers, HasLen, 5)
+	c.Assert(schedulers[2], DeepEquals, SchedulerConfig{Type: "hot-region", Disable: true})
+	c.Assert(schedulers[4], DeepEquals, SchedulerConfig{Type: "hot-region", Args: []string{"schedule-factor=0.8", "limit-factor=0.5"}})
+
+	// Removing the scheduler disables the entry with the arguments.
+	c.Assert(newOpt.RemoveSchedulerCfg("balance-hot-region-scheduler"), IsNil)
+	schedulers = newOpt.GetSchedulers()
+	c.Assert(schedulers[2].Disable, IsTrue)
+	c.Assert(schedulers[4].Disable, IsTrue)
+}
+
 func (s *testConfigSuite) TestValidation(c *C) {
 	cfg := NewConfig()
 	c.Assert(cfg.Adjust(nil), IsNil)
@@ -82,6 +106,13 @@ func (s *testConfigSuite) TestValidation(c *C) {
 	c.Assert(cfg.Schedule.validate(), NotNil)
 	cfg.Schedule.LowSpaceRatio = 0.8
 	c.Assert(cfg.Schedule.validate(), IsNil)
+	cfg.Schedule.HotRegionModelURL = "localhost:8000/model"
+	c.Assert(cfg.Schedule.validate(), NotNil)
+	// No model service.
+	cfg.Schedule.HotRegionModelURL = ""
+	c.Assert(cfg.Schedule.validate(), IsNil)
+	cfg.Schedule.HotRegionModelURL = "http://localhost:8000/model/pd"
+	c.Assert(cfg.Schedule.validate(), IsNil)
 	cfg.Schedule.TolerantSizeRatio = -0.6
 	c.Assert(cfg.Schedule.validate(), NotNil)
 }
@@ -94,6 +125,8 @@ lease = 0
 [schedule]
 max-merge-region-size = 0
 leader-schedule-limit = 0
+hot-region-min-flow-bytes = 0
+disable-hot-region-model = true
 `
 	cfg := NewConfig()
 	meta, err := toml.Decode(cfgData, &cfg)
@@ -107,7 +140,14 @@ leader-schedule-limit = 0
 	// When defined, use values from config file.
 	c.Assert(cfg.Schedule.MaxMergeRegionSize, Equals, uint64(0))
 	c.Assert(cfg.Schedule.LeaderScheduleLimit, Equals, uint64(0))
+	c.Assert(cfg.Schedule.HotRegionMinFlowBytes, Equals, uint64(0))
+	c.Assert(cfg.Schedule.DisableHotRegionModel, IsTrue)
 	// When undefined, use default values.
 	c.Assert(cfg.PreVote, IsTrue)
 	c.Assert(cfg.Schedule.MaxMergeRegionKeys, Equals, uint64(defaultMaxMergeRegionKeys))
+	// No model service is configured by default.
+	c.Assert(cfg.Schedule.HotRegionModelURL, Equals, "")
+	cfg = NewConfig()
+	c.Assert(cfg.Adjust(nil), IsNil)
+	c.Assert(cfg.Schedule.HotRegionMinFlowBytes, Equals, uint64(defaultHotRegionMinFlowBytes))
 }
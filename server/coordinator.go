@@ -16,6 +16,8 @@ package server
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"sync"
 	"time"
 
@@ -33,6 +35,12 @@ const (
 	collectTimeout            = 5 * time.Minute
 	maxScheduleRetries        = 10
 
+	// A scheduler asking for an expedited run is run again after
+	// expeditedScheduleDelay, at most maxExpeditedTicksPerMinute times per
+	// minute to prevent loops.
+	expeditedScheduleDelay     = 100 * time.Millisecond
+	maxExpeditedTicksPerMinute = 10
+
 	regionheartbeatSendChanCap = 1024
 	hotRegionScheduleName      = "balance-hot-region-scheduler"
 
@@ -60,6 +68,7 @@ type coordinator struct {
 	opController     *schedule.OperatorController
 	classifier       namespace.Classifier
 	hbStreams        *heartbeatStreams
+	rounds           *roundCollector
 }
 
 func newCoordinator(cluster *clusterInfo, hbStreams *heartbeatStreams, classifier namespace.Classifier) *coordinator {
@@ -76,6 +85,7 @@ func newCoordinator(cluster *clusterInfo, hbStreams *heartbeatStreams, classifie
 		opController:     schedule.NewOperatorController(cluster, hbStreams),
 		classifier:       classifier,
 		hbStreams:        hbStreams,
+		rounds:           newRoundCollector(scheduleRoundDuration, scheduleRoundCapacity),
 	}
 }
 
@@ -234,6 +244,20 @@ func (c *coordinator) stop() {
 type hasHotStatus interface {
 	GetHotReadStatus() *core.StoreHotRegionInfos
 	GetHotWriteStatus() *core.StoreHotRegionInfos
+	GetHotStatus() (read, write *core.StoreHotRegionInfos)
+}
+
+func (c *coordinator) getHotRegions() (read, write *core.StoreHotRegionInfos) {
+	c.RLock()
+	defer c.RUnlock()
+	s, ok := c.schedulers[hotRegionScheduleName]
+	if !ok {
+		return nil, nil
+	}
+	if h, ok := s.Scheduler.(hasHotStatus); ok {
+		return h.GetHotStatus()
+	}
+	return nil, nil
 }
 
 func (c *coordinator) getHotWriteRegions() *core.StoreHotRegionInfos {
@@ -262,6 +286,186 @@ func (c *coordinator) getHotReadRegions() *core.StoreHotRegionInfos {
 	return nil
 }
 
+type hasHotHistory interface {
+	GetHotRegionHistory(limit int) []core.HotRegionDecision
+	GetHotRegionDailyReport(days int) []core.HotRegionDailyReport
+}
+
+func (c *coordinator) getHotRegionHistory(limit int) []core.HotRegionDecision {
+	c.RLock()
+	defer c.RUnlock()
+	s, ok := c.schedulers[hotRegionScheduleName]
+	if !ok {
+		return nil
+	}
+	if h, ok := s.Scheduler.(hasHotHistory); ok {
+		return h.GetHotRegionHistory(limit)
+	}
+	return nil
+}
+
+func (c *coordinator) getHotRegionDailyReport(days int) []core.HotRegionDailyReport {
+	c.RLock()
+	defer c.RUnlock()
+	s, ok := c.schedulers[hotRegionScheduleName]
+	if !ok {
+		return nil
+	}
+	if h, ok := s.Scheduler.(hasHotHistory); ok {
+		return h.GetHotRegionDailyReport(days)
+	}
+	return nil
+}
+
+type hasHotChanges interface {
+	GetHotSchedulerChanges(limit int) []core.HotSchedulerChange
+}
+
+func (c *coordinator) getHotSchedulerChanges(limit int) []core.HotSchedulerChange {
+	c.RLock()
+	defer c.RUnlock()
+	s, ok := c.schedulers[hotRegionScheduleName]
+	if !ok {
+		return nil
+	}
+	if h, ok := s.Scheduler.(hasHotChanges); ok {
+		return h.GetHotSchedulerChanges(limit)
+	}
+	return nil
+}
+
+type hasHotSupportBundle interface {
+	SupportBundle(w io.Writer) error
+}
+
+func (c *coordinator) writeHotSupportBundle(w io.Writer) error {
+	c.RLock()
+	defer c.RUnlock()
+	s, ok := c.schedulers[hotRegionScheduleName]
+	if !ok {
+		return errSchedulerNotFound
+	}
+	h, ok := s.Scheduler.(hasHotSupportBundle)
+	if !ok {
+		return errSchedulerNotFound
+	}
+	return h.SupportBundle(w)
+}
+
+type hasHotStoreExclusion interface {
+	GetStoreExclusion() core.HotStoreExclusion
+	SetStoreExclusion(cfg core.HotStoreExclusion) error
+}
+
+func (c *coordinator) getHotStoreExclusion() (core.HotStoreExclusion, error) {
+	c.RLock()
+	defer c.RUnlock()
+	s, ok := c.schedulers[hotRegionScheduleName]
+	if !ok {
+		return core.HotStoreExclusion{}, errSchedulerNotFound
+	}
+	h, ok := s.Scheduler.(hasHotStoreExclusion)
+	if !ok {
+		return core.HotStoreExclusion{}, errSchedulerNotFound
+	}
+	return h.GetStoreExclusion(), nil
+}
+
+func (c *coordinator) setHotStoreExclusion(cfg core.HotStoreExclusion) error {
+	c.RLock()
+	defer c.RUnlock()
+	s, ok := c.schedulers[hotRegionScheduleName]
+	if !ok {
+		return errSchedulerNotFound
+	}
+	h, ok := s.Scheduler.(hasHotStoreExclusion)
+	if !ok {
+		return errSchedulerNotFound
+	}
+	return h.SetStoreExclusion(cfg)
+}
+
+type hasHotTargetBlacklist interface {
+	AddTargetBlacklist(storeID uint64) error
+	RemoveTargetBlacklist(storeID uint64) error
+}
+
+func (c *coordinator) setHotTargetBlacklist(storeID uint64, blacklisted bool) error {
+	c.RLock()
+	defer c.RUnlock()
+	s, ok := c.schedulers[hotRegionScheduleName]
+	if !ok {
+		return errSchedulerNotFound
+	}
+	h, ok := s.Scheduler.(hasHotTargetBlacklist)
+	if !ok {
+		return errSchedulerNotFound
+	}
+	if blacklisted {
+		return h.AddTargetBlacklist(storeID)
+	}
+	return h.RemoveTargetBlacklist(storeID)
+}
+
+type hasHotBalanceTypes interface {
+	SetBalanceTypes(names []string) error
+}
+
+func (c *coordinator) setHotBalanceTypes(names []string) error {
+	c.RLock()
+	defer c.RUnlock()
+	s, ok := c.schedulers[hotRegionScheduleName]
+	if !ok {
+		return errSchedulerNotFound
+	}
+	h, ok := s.Scheduler.(hasHotBalanceTypes)
+	if !ok {
+		return errSchedulerNotFound
+	}
+	return h.SetBalanceTypes(names)
+}
+
+type hasHotDryRun interface {
+	SetDryRun(dryRun bool)
+}
+
+func (c *coordinator) setHotDryRun(dryRun bool) error {
+	c.RLock()
+	defer c.RUnlock()
+	s, ok := c.schedulers[hotRegionScheduleName]
+	if !ok {
+		return errSchedulerNotFound
+	}
+	h, ok := s.Scheduler.(hasHotDryRun)
+	if !ok {
+		return errSchedulerNotFound
+	}
+	h.SetDryRun(dryRun)
+	return nil
+}
+
+// hasHotConfigStorage is implemented by the hot region schedulers which
+// persist their configs changed at runtime.
+type hasHotConfigStorage interface {
+	SetConfigStorage(storage schedule.ConfigStorage) error
+}
+
+// getSchedulerHandler returns the running scheduler with the name if it
+// implements http.Handler.
+func (c *coordinator) getSchedulerHandler(name string) (http.Handler, error) {
+	c.RLock()
+	defer c.RUnlock()
+	s, ok := c.schedulers[name]
+	if !ok {
+		return nil, errSchedulerNotFound
+	}
+	h, ok := s.Scheduler.(http.Handler)
+	if !ok {
+		return nil, errors.WithStack(ErrSchedulerNotSupportHTTP)
+	}
+	return h, nil
+}
+
 func (c *coordinator) getSchedulers() []string {
 	c.RLock()
 	defer c.RUnlock()
@@ -273,6 +477,10 @@ func (c *coordinator) getSchedulers() []string {
 	return names
 }
 
+func (c *coordinator) getScheduleRounds() []*ScheduleRound {
+	return c.rounds.getRounds()
+}
+
 func (c *coordinator) collectSchedulerMetrics() {
 	c.RLock()
 	defer c.RUnlock()
@@ -294,7 +502,7 @@ func (c *coordinator) collectHotSpotMetrics() {
 		return
 	}
 	stores := c.cluster.GetStores()
-	status := s.Scheduler.(hasHotStatus).GetHotWriteStatus()
+	readStatus, status := s.Scheduler.(hasHotStatus).GetHotStatus()
 	for _, s := range stores {
 		store := fmt.Sprintf("store_%d", s.GetId())
 		stat, ok := status.AsPeer[s.GetId()]
@@ -323,7 +531,7 @@ func (c *coordinator) collectHotSpotMetrics() {
 	}
 
 	// collect hot read region metrics
-	status = s.Scheduler.(hasHotStatus).GetHotReadStatus()
+	status = readStatus
 	for _, s := range stores {
 		store := fmt.Sprintf("store_%d", s.GetId())
 		stat, ok := status.AsLeader[s.GetId()]
@@ -353,6 +561,12 @@ func (c *coordinator) addScheduler(scheduler schedule.Scheduler, args ...string)
 		return errSchedulerExisted
 	}
 
+	if h, ok := scheduler.(hasHotConfigStorage); ok && c.cluster.kv != nil {
+		if err := h.SetConfigStorage(c.cluster.kv); err != nil {
+			log.Errorf("can not restore the config of scheduler %s: %v", scheduler.GetName(), err)
+		}
+	}
+
 	s := newScheduleController(c, scheduler)
 	if err := s.Prepare(c.cluster); err != nil {
 		return err
@@ -378,6 +592,12 @@ func (c *coordinator) removeScheduler(name string) error {
 	s.Stop()
 	schedulerStatusGauge.WithLabelValues(name, "allow").Set(0)
 	delete(c.schedulers, name)
+	// The scheduler added later starts from its arguments.
+	if _, ok := s.Scheduler.(hasHotConfigStorage); ok && c.cluster.kv != nil {
+		if err := c.cluster.kv.RemoveSchedulerConfig(s.GetType()); err != nil {
+			log.Errorf("can not remove the config of scheduler %s: %v", name, err)
+		}
+	}
 
 	return c.cluster.opt.RemoveSchedulerCfg(name)
 }
@@ -395,11 +615,24 @@ func (c *coordinator) runScheduler(s *scheduleController) {
 		case <-timer.C:
 			timer.Reset(s.GetInterval())
 			if !s.AllowSchedule() {
+				c.rounds.record(time.Now(), s.GetName(), 0, skipReasonNotAllowed, nil)
 				continue
 			}
-			if op := s.Schedule(); op != nil {
+			op := s.Schedule()
+			if op != nil {
 				c.opController.AddOperator(op...)
+				if s.expedite(time.Now()) {
+					if !timer.Stop() {
+						<-timer.C
+					}
+					timer.Reset(expeditedScheduleDelay)
+				}
 			}
+			var summary map[string]string
+			if p, ok := s.Scheduler.(schedule.SummaryProvider); ok {
+				summary = p.GetSummary()
+			}
+			c.rounds.record(time.Now(), s.GetName(), len(op), "", summary)
 
 		case <-s.Ctx().Done():
 			log.Infof("%v stopped: %v", s.GetName(), s.Ctx().Err())
@@ -416,6 +649,9 @@ type scheduleController struct {
 	nextInterval time.Duration
 	ctx          context.Context
 	cancel       context.CancelFunc
+	// expeditedTicks is the number of expedited runs since expediteStart.
+	expeditedTicks int
+	expediteStart  time.Time
 }
 
 func newScheduleController(c *coordinator, s schedule.Scheduler) *scheduleController {
@@ -451,6 +687,25 @@ func (s *scheduleController) Schedule() []*schedule.Operator {
 	return nil
 }
 
+// expedite returns true if the scheduler asks for an expedited run which is
+// sooner than the next normal run and the cap per minute is not reached.
+func (s *scheduleController) expedite(now time.Time) bool {
+	e, ok := s.Scheduler.(schedule.ExpeditedScheduler)
+	if !ok || !e.NeedExpeditedTick() || s.nextInterval <= expeditedScheduleDelay {
+		return false
+	}
+	if now.Sub(s.expediteStart) >= time.Minute {
+		s.expediteStart = now
+		s.expeditedTicks = 0
+	}
+	if s.expeditedTicks >= maxExpeditedTicksPerMinute {
+		return false
+	}
+	s.expeditedTicks++
+	schedulerExpeditedTickCounter.WithLabelValues(s.GetName()).Inc()
+	return true
+}
+
 func (s *scheduleController) GetInterval() time.Duration {
 	return s.nextInterval
 }
@@ -16,6 +16,7 @@ package server
 import (
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	. "github.com/pingcap/check"
@@ -899,3 +900,173 @@ func waitNoResponse(c *C, stream *mockHeartbeatStream) {
 		return res == nil
 	})
 }
+
+// dummyScheduler creates an empty operator for region 1 every time.
+type dummyScheduler struct {
+	schedule.Scheduler
+}
+
+func (s *dummyScheduler) GetName() string {
+	return "dummy-scheduler"
+}
+
+func (s *dummyScheduler) IsScheduleAllowed(cluster schedule.Cluster) bool {
+	return true
+}
+
+func (s *dummyScheduler) Schedule(cluster schedule.Cluster) []*schedule.Operator {
+	return []*schedule.Operator{newTestOperator(1, cluster.GetRegion(1).GetRegionEpoch(), schedule.OpLeader)}
+}
+
+func (s *testCoordinatorSuite) TestScheduleRounds(c *C) {
+	_, opt := newTestScheduleConfig()
+	tc := newTestClusterInfo(opt)
+	hbStreams := newHeartbeatStreams(tc.getClusterID())
+	defer hbStreams.Close()
+
+	tc.addLeaderStore(1, 1)
+	tc.addLeaderStore(2, 1)
+	tc.addLeaderRegion(1, 1, 2)
+
+	co := newCoordinator(tc.clusterInfo, hbStreams, namespace.DefaultClassifier)
+	// Collect all runs into one round.
+	co.rounds = newRoundCollector(time.Hour, scheduleRoundCapacity)
+	defer co.wg.Wait()
+	defer co.stop()
+
+	hot, err := schedule.CreateScheduler("hot-region", co.opController)
+	c.Assert(err, IsNil)
+	c.Assert(co.addScheduler(hot), IsNil)
+	bl, err := schedule.CreateScheduler("balance-leader", co.opController)
+	c.Assert(err, IsNil)
+	c.Assert(co.addScheduler(&dummyScheduler{Scheduler: bl}), IsNil)
+
+	var records []*SchedulerRoundRecord
+	testutil.WaitUntil(c, func(c *C) bool {
+		rounds := co.getScheduleRounds()
+		if len(rounds) != 1 {
+			return false
+		}
+		records = rounds[0].Schedulers
+		return len(records) == 2 && records[0].Runs > records[0].Skipped && records[1].Runs > 0
+	})
+
+	// The hot scheduler provides its decision summary.
+	c.Assert(records[0].Name, Equals, hotRegionScheduleName)
+	c.Assert(records[0].Operators, Equals, 0)
+	c.Assert(records[0].Summary["reason"], Equals, "skip")
+	c.Assert(records[0].Summary["balance-type"], Not(Equals), "")
+	// The dummy scheduler only has the operator count.
+	c.Assert(records[1].Name, Equals, "dummy-scheduler")
+	c.Assert(records[1].Operators, Equals, records[1].Runs)
+	c.Assert(records[1].Skipped, Equals, 0)
+	c.Assert(records[1].Summary, IsNil)
+}
+
+func (s *testCoordinatorSuite) TestRoundCollector(c *C) {
+	rc := newRoundCollector(time.Second, 2)
+	start := time.Now()
+	c.Assert(rc.getRounds(), HasLen, 0)
+
+	rc.record(start, "b", 1, "", nil)
+	rc.record(start.Add(time.Millisecond), "a", 0, skipReasonNotAllowed, nil)
+	rounds := rc.getRounds()
+	c.Assert(rounds, HasLen, 1)
+	c.Assert(rounds[0].Start, Equals, start)
+	c.Assert(rounds[0].Schedulers, HasLen, 2)
+	c.Assert(*rounds[0].Schedulers[0], DeepEquals, SchedulerRoundRecord{Name: "a", Runs: 1, Skipped: 1, SkipReason: skipReasonNotAllowed})
+	c.Assert(*rounds[0].Schedulers[1], DeepEquals, SchedulerRoundRecord{Name: "b", Runs: 1, Operators: 1})
+
+	// Only the last 2 finished rounds are kept.
+	for i := 1; i <= 3; i++ {
+		rc.record(start.Add(time.Duration(i)*time.Second), "a", i, "", map[string]string{"round": fmt.Sprint(i)})
+	}
+	rounds = rc.getRounds()
+	c.Assert(rounds, HasLen, 3)
+	c.Assert(rounds[0].Schedulers[0].Operators, Equals, 1)
+	c.Assert(rounds[1].Schedulers[0].Summary, DeepEquals, map[string]string{"round": "2"})
+	c.Assert(rounds[2].Start, Equals, start.Add(3*time.Second))
+	c.Assert(rounds[2].Schedulers, HasLen, 1)
+}
+
+// expeditedScheduler records its runs and asks for an expedited run every
+// time if expedite is true.
+type expeditedScheduler struct {
+	dummyScheduler
+	expedite bool
+
+	sync.Mutex
+	runs []time.Time
+}
+
+func (s *expeditedScheduler) GetMinInterval() time.Duration {
+	return time.Hour
+}
+
+func (s *expeditedScheduler) Schedule(cluster schedule.Cluster) []*schedule.Operator {
+	s.Lock()
+	s.runs = append(s.runs, time.Now())
+	s.Unlock()
+	return s.dummyScheduler.Schedule(cluster)
+}
+
+func (s *expeditedScheduler) NeedExpeditedTick() bool {
+	return s.expedite
+}
+
+func (s *expeditedScheduler) getRuns() []time.Time {
+	s.Lock()
+	defer s.Unlock()
+	return append([]time.Time(nil), s.runs...)
+}
+
+func (s *testScheduleControllerSuite) TestExpedite(c *C) {
+	_, opt := newTestScheduleConfig()
+	tc := newTestClusterInfo(opt)
+	hbStreams := newHeartbeatStreams(tc.getClusterID())
+	defer hbStreams.Close()
+	tc.addLeaderRegion(1, 1)
+
+	co := newCoordinator(tc.clusterInfo, hbStreams, namespace.DefaultClassifier)
+	defer co.wg.Wait()
+	defer co.stop()
+	bl, err := schedule.CreateScheduler("balance-leader", co.opController)
+	c.Assert(err, IsNil)
+
+	// The cap of the expedited runs is reset every minute.
+	es := &expeditedScheduler{dummyScheduler: dummyScheduler{Scheduler: bl}, expedite: true}
+	sc := newScheduleController(co, es)
+	now := time.Now()
+	for i := 0; i < maxExpeditedTicksPerMinute; i++ {
+		c.Assert(sc.expedite(now), IsTrue)
+	}
+	c.Assert(sc.expedite(now.Add(time.Second)), IsFalse)
+	c.Assert(sc.expedite(now.Add(time.Minute)), IsTrue)
+	// No expedited run if the next normal run is sooner.
+	sc.nextInterval = expeditedScheduleDelay
+	c.Assert(sc.expedite(now.Add(2*time.Minute)), IsFalse)
+	es.expedite = false
+	sc.nextInterval = time.Hour
+	c.Assert(sc.expedite(now.Add(2*time.Minute)), IsFalse)
+
+	// Run the schedulers once soon, then the one asking for the expedited
+	// runs is run again until the cap is reached.
+	normal := &expeditedScheduler{dummyScheduler: dummyScheduler{Scheduler: bl}}
+	expedited := &expeditedScheduler{dummyScheduler: dummyScheduler{Scheduler: bl}, expedite: true}
+	for _, s := range []*expeditedScheduler{normal, expedited} {
+		sc := newScheduleController(co, s)
+		sc.nextInterval = time.Millisecond
+		co.wg.Add(1)
+		go co.runScheduler(sc)
+	}
+	testutil.WaitUntil(c, func(c *C) bool {
+		return len(expedited.getRuns()) == maxExpeditedTicksPerMinute+1
+	})
+	time.Sleep(2 * expeditedScheduleDelay)
+	runs := expedited.getRuns()
+	c.Assert(runs, HasLen, maxExpeditedTicksPerMinute+1)
+	for i := 1; i < len(runs); i++ {
+		c.Assert(runs[i].Sub(runs[i-1]) >= expeditedScheduleDelay, IsTrue)
+	}
+	c.Assert(normal.getRuns(), HasLen, 1)
+}
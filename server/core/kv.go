@@ -27,10 +27,11 @@ import (
 )
 
 const (
-	clusterPath  = "raft"
-	configPath   = "config"
-	schedulePath = "schedule"
-	gcPath       = "gc"
+	clusterPath   = "raft"
+	configPath    = "config"
+	schedulePath  = "schedule"
+	schedulerPath = "scheduler"
+	gcPath        = "gc"
 )
 
 const (
@@ -171,6 +172,42 @@ func (kv *KV) LoadConfig(cfg interface{}) (bool, error) {
 	return true, nil
 }
 
+func schedulerConfigPath(schedulerType string) string {
+	return path.Join(schedulerPath, schedulerType, "config")
+}
+
+// SaveSchedulerConfig stores marshalable cfg of the scheduler type, e.g. the
+// config of hot-region is stored to "scheduler/hot-region/config".
+func (kv *KV) SaveSchedulerConfig(schedulerType string, cfg interface{}) error {
+	value, err := json.Marshal(cfg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return kv.Save(schedulerConfigPath(schedulerType), string(value))
+}
+
+// LoadSchedulerConfig loads the config of the scheduler type then unmarshal
+// it to cfg.
+func (kv *KV) LoadSchedulerConfig(schedulerType string, cfg interface{}) (bool, error) {
+	value, err := kv.Load(schedulerConfigPath(schedulerType))
+	if err != nil {
+		return false, err
+	}
+	if value == "" {
+		return false, nil
+	}
+	err = json.Unmarshal([]byte(value), cfg)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return true, nil
+}
+
+// RemoveSchedulerConfig removes the config of the scheduler type.
+func (kv *KV) RemoveSchedulerConfig(schedulerType string) error {
+	return kv.Delete(schedulerConfigPath(schedulerType))
+}
+
 // LoadStores loads all stores from KV to StoresInfo.
 func (kv *KV) LoadStores(stores *StoresInfo) error {
 	nextID := uint64(0)
@@ -193,3 +193,24 @@ func newTestRegionMeta(regionID uint64) *metapb.Region {
 		EndKey:   []byte(fmt.Sprintf("%20d", regionID+1)),
 	}
 }
+
+func (s *testKVSuite) TestSchedulerConfig(c *C) {
+	kv := NewKV(NewMemoryKV())
+	c.Assert(schedulerConfigPath("hot-region"), Equals, "scheduler/hot-region/config")
+
+	var cfg map[string]int
+	ok, err := kv.LoadSchedulerConfig("hot-region", &cfg)
+	c.Assert(ok, IsFalse)
+	c.Assert(err, IsNil)
+
+	c.Assert(kv.SaveSchedulerConfig("hot-region", map[string]int{"limit": 4}), IsNil)
+	ok, err = kv.LoadSchedulerConfig("hot-region", &cfg)
+	c.Assert(ok, IsTrue)
+	c.Assert(err, IsNil)
+	c.Assert(cfg, DeepEquals, map[string]int{"limit": 4})
+
+	c.Assert(kv.RemoveSchedulerConfig("hot-region"), IsNil)
+	ok, err = kv.LoadSchedulerConfig("hot-region", &cfg)
+	c.Assert(ok, IsFalse)
+	c.Assert(err, IsNil)
+}
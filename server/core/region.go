@@ -37,7 +37,9 @@ type RegionInfo struct {
 	downPeers       []*pdpb.PeerStats
 	pendingPeers    []*metapb.Peer
 	writtenBytes    uint64
+	writtenKeys     uint64
 	readBytes       uint64
+	readKeys        uint64
 	approximateSize int64
 	approximateKeys int64
 }
@@ -90,7 +92,9 @@ func RegionFromHeartbeat(heartbeat *pdpb.RegionHeartbeatRequest) *RegionInfo {
 		downPeers:       heartbeat.GetDownPeers(),
 		pendingPeers:    heartbeat.GetPendingPeers(),
 		writtenBytes:    heartbeat.GetBytesWritten(),
+		writtenKeys:     heartbeat.GetKeysWritten(),
 		readBytes:       heartbeat.GetBytesRead(),
+		readKeys:        heartbeat.GetKeysRead(),
 		approximateSize: int64(regionSize),
 		approximateKeys: int64(heartbeat.GetApproximateKeys()),
 	}
@@ -116,7 +120,9 @@ func (r *RegionInfo) Clone(opts ...RegionCreateOption) *RegionInfo {
 		downPeers:       downPeers,
 		pendingPeers:    pendingPeers,
 		writtenBytes:    r.writtenBytes,
+		writtenKeys:     r.writtenKeys,
 		readBytes:       r.readBytes,
+		readKeys:        r.readKeys,
 		approximateSize: r.approximateSize,
 		approximateKeys: r.approximateKeys,
 	}
@@ -329,6 +335,16 @@ func (r *RegionInfo) GetBytesWritten() uint64 {
 	return r.writtenBytes
 }
 
+// GetKeysRead returns the read keys of the region.
+func (r *RegionInfo) GetKeysRead() uint64 {
+	return r.readKeys
+}
+
+// GetKeysWritten returns the written keys of the region.
+func (r *RegionInfo) GetKeysWritten() uint64 {
+	return r.writtenKeys
+}
+
 // GetLeader returns the leader of the region.
 func (r *RegionInfo) GetLeader() *metapb.Peer {
 	return r.leader
@@ -358,6 +374,11 @@ func (r *RegionInfo) GetRegionEpoch() *metapb.RegionEpoch {
 type RegionStat struct {
 	RegionID  uint64 `json:"region_id"`
 	FlowBytes uint64 `json:"flow_bytes"`
+	// FlowBytesP99 is the 99th percentile of the recent flow bytes, while
+	// FlowBytes is the median.
+	FlowBytesP99 uint64 `json:"flow_bytes_p99,omitempty"`
+	// FlowKeys is the number of keys read or written per second.
+	FlowKeys uint64 `json:"flow_keys"`
 	// HotDegree records the hot region update times
 	HotDegree int `json:"hot_degree"`
 	// LastUpdateTime used to calculate average write
@@ -369,6 +390,20 @@ type RegionStat struct {
 	Version uint64
 	// Stats is a rolling statistics, recording some recently added records.
 	Stats *RollingStats
+	// CoprocessorFlowBytes and GetFlowBytes break the read flow down into
+	// the coprocessor requests and the point gets, both of them are 0 if
+	// the breakdown is not reported.
+	CoprocessorFlowBytes uint64 `json:"coprocessor_flow_bytes,omitempty"`
+	GetFlowBytes         uint64 `json:"get_flow_bytes,omitempty"`
+	// SplitCandidate indicates the region is hot by coprocessor scans,
+	// which is better fixed by splitting than moving it.
+	SplitCandidate bool `json:"split_candidate,omitempty"`
+}
+
+// HasReadBreakdown returns true if the read flow of the region is broken
+// down into the coprocessor requests and the point gets.
+func (stat *RegionStat) HasReadBreakdown() bool {
+	return stat.CoprocessorFlowBytes != 0 || stat.GetFlowBytes != 0
 }
 
 // NewRegionStat returns a RegionStat.
@@ -393,6 +428,7 @@ func (m RegionsStat) Less(i, j int) bool { return m[i].FlowBytes < m[j].FlowByte
 // HotRegionsStat records all hot regions statistics
 type HotRegionsStat struct {
 	TotalFlowBytes uint64      `json:"total_flow_bytes"`
+	TotalFlowKeys  uint64      `json:"total_flow_keys"`
 	RegionsCount   int         `json:"regions_count"`
 	RegionsStat    RegionsStat `json:"statistics"`
 }
@@ -14,6 +14,9 @@
 package core
 
 import (
+	"math"
+	"sort"
+
 	"github.com/montanaflynn/stats"
 )
 
@@ -39,6 +42,16 @@ func (r *RollingStats) Add(n float64) {
 	r.count++
 }
 
+// Clone returns a copy of the statistics, which is not changed by the
+// records added to the origin.
+func (r *RollingStats) Clone() *RollingStats {
+	return &RollingStats{
+		records: append([]float64(nil), r.records...),
+		size:    r.size,
+		count:   r.count,
+	}
+}
+
 // Median returns the median of the records.
 // it can be used to filter noise.
 // References: https://en.wikipedia.org/wiki/Median_filter.
@@ -53,3 +66,19 @@ func (r *RollingStats) Median() float64 {
 	median, _ := stats.Median(records)
 	return median
 }
+
+// Percentile returns the p-th percentile of the records by the nearest rank,
+// p is in (0, 100]. Unlike the median, it keeps the bursts in the records.
+func (r *RollingStats) Percentile(p float64) uint64 {
+	if r.count == 0 || p <= 0 || p > 100 {
+		return 0
+	}
+	records := r.records
+	if r.count < r.size {
+		records = r.records[:r.count]
+	}
+	sorted := append([]float64(nil), records...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return uint64(sorted[rank-1])
+}
@@ -30,3 +30,30 @@ func (t *testRollingStats) TestRollingMedian(c *C) {
 		c.Assert(stats.Median(), Equals, expected[i])
 	}
 }
+
+func (t *testRollingStats) TestRollingPercentile(c *C) {
+	stats := NewRollingStats(5)
+	c.Assert(stats.Percentile(99), Equals, uint64(0))
+	data := []float64{2, 4, 2, 800, 600, 6, 3}
+	expected := []uint64{2, 4, 4, 800, 800, 800, 800}
+	for i, e := range data {
+		stats.Add(e)
+		c.Assert(stats.Percentile(99), Equals, expected[i])
+	}
+	// The records are 6, 3, 2, 800, 600 at last.
+	c.Assert(stats.Percentile(50), Equals, uint64(6))
+	c.Assert(stats.Percentile(40), Equals, uint64(3))
+	c.Assert(stats.Percentile(0), Equals, uint64(0))
+}
+
+func (t *testRollingStats) TestRollingClone(c *C) {
+	stats := NewRollingStats(3)
+	stats.Add(1)
+	stats.Add(5)
+	clone := stats.Clone()
+	stats.Add(800)
+	stats.Add(900)
+	c.Assert(clone.Median(), Equals, 3.0)
+	c.Assert(clone.Percentile(100), Equals, uint64(5))
+	c.Assert(stats.Median(), Equals, 800.0)
+}
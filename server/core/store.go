@@ -309,8 +309,160 @@ L:
 
 // StoreHotRegionInfos : used to get human readable description for hot regions.
 type StoreHotRegionInfos struct {
-	AsPeer   StoreHotRegionsStat `json:"as_peer"`
-	AsLeader StoreHotRegionsStat `json:"as_leader"`
+	AsPeer      StoreHotRegionsStat    `json:"as_peer"`
+	AsLeader    StoreHotRegionsStat    `json:"as_leader"`
+	LeaderLabel *LeaderLabelConstraint `json:"leader_label,omitempty"`
+	// Limit is the current limit of the running hot region operators of the
+	// read or write balancing.
+	Limit uint64 `json:"limit,omitempty"`
+	// Advisories are the remediations suggested instead of operators.
+	Advisories []HotRegionAdvisory `json:"advisories,omitempty"`
+	// Exclusion is the stores excluded from the scheduling.
+	Exclusion *HotStoreExclusion `json:"exclusion,omitempty"`
+	// PendingFlowBytes is the flow bytes moving into (positive) and out of
+	// (negative) the stores by the running hot region operators, sorted by
+	// the store ID.
+	PendingFlowBytes []HotStorePendingFlow `json:"pending_flow_bytes,omitempty"`
+	// ClockSkew is the stores whose hot region stats are mostly ahead of
+	// PD, the clocks of which are likely skewed.
+	ClockSkew []HotStoreClockSkew `json:"clock_skew,omitempty"`
+	// LastUpdateTime is the time the statistics are calculated, zero if they
+	// are never calculated.
+	LastUpdateTime time.Time `json:"last_update_time"`
+	// DisabledFeatures are the features enabled by the config but disabled
+	// as they are not supported by the cluster version.
+	DisabledFeatures []string `json:"disabled_features,omitempty"`
+}
+
+// HotStorePendingFlow : the flow bytes moving into (positive) and out of
+// (negative) a store by the running hot region operators.
+type HotStorePendingFlow struct {
+	StoreID   uint64 `json:"store_id"`
+	FlowBytes int64  `json:"flow_bytes"`
+}
+
+// HotStoreClockSkew : the future-dated hot region stats of a store, they are
+// clamped to the time of PD by the hot region scheduler.
+type HotStoreClockSkew struct {
+	StoreID uint64 `json:"store_id"`
+	// FutureStatsCount is the number of the future-dated stats since the
+	// scheduler starts.
+	FutureStatsCount uint64 `json:"future_stats_count"`
+	// FutureStatsRatio is the ratio of the future-dated stats in the last
+	// calculation.
+	FutureStatsRatio float64 `json:"future_stats_ratio"`
+}
+
+// HotRegionAdvisory : a remediation of a hot region suggested by the hot
+// region scheduler instead of creating an operator, which external systems
+// can act on.
+type HotRegionAdvisory struct {
+	Time     time.Time `json:"time"`
+	RegionID uint64    `json:"region_id"`
+	StoreID  uint64    `json:"store_id"`
+	// Advice is "follower-read-sufficient" if the follower reads are
+	// estimated to cool the region down.
+	Advice string `json:"advice"`
+	// FlowBytes is the read flow bytes of the region leader, and
+	// EstimatedFlowBytes is the estimation after the follower reads offload.
+	FlowBytes          uint64 `json:"flow_bytes"`
+	EstimatedFlowBytes uint64 `json:"estimated_flow_bytes"`
+	HotThreshold       uint64 `json:"hot_threshold"`
+}
+
+// HotRegionDecision : a decision made by the hot region scheduler.
+type HotRegionDecision struct {
+	Time          time.Time `json:"time"`
+	RegionID      uint64    `json:"region_id"`
+	SourceStoreID uint64    `json:"source_store_id"`
+	DestStoreID   uint64    `json:"dest_store_id"`
+	BalanceType   string    `json:"balance_type"`
+	RegionSize    int64     `json:"region_size"`
+	FlowBytes     uint64    `json:"flow_bytes"`
+	// Features are the strategy features which triggered the decision,
+	// sorted by the feature name.
+	Features []HotDecisionFeature `json:"features,omitempty"`
+}
+
+// HotDecisionFeature : a strategy feature of a hot region decision.
+type HotDecisionFeature struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// The sources of the changes of the hot region scheduler.
+const (
+	// HotChangeSourceInternal is a change made by the scheduler itself, e.g.
+	// the limit adjustment or the circuit breaker.
+	HotChangeSourceInternal = "internal"
+	// HotChangeSourceUser is a change requested by the user through the API.
+	HotChangeSourceUser = "user"
+)
+
+// HotSchedulerChange : a change of the hot region scheduler's behavior, made
+// by the scheduler itself or by the user as told by the source.
+type HotSchedulerChange struct {
+	Time   time.Time `json:"time"`
+	Name   string    `json:"name"`
+	Old    string    `json:"old"`
+	New    string    `json:"new"`
+	Source string    `json:"source"`
+	Reason string    `json:"reason"`
+}
+
+// HotModelConfig : how the hot region scheduler uses the model service.
+type HotModelConfig struct {
+	// Mode is one of "off", "shadow" and "active".
+	Mode string `json:"mode"`
+	// Threshold is the minimal probability of a prediction to be followed in
+	// the active mode.
+	Threshold float64 `json:"threshold"`
+}
+
+// HotStoreExclusion : the stores the hot region scheduler neither moves hot
+// regions from nor to. A store is excluded if its ID is listed or it has any
+// of the labels.
+type HotStoreExclusion struct {
+	StoreIDs []uint64             `json:"store_ids,omitempty"`
+	Labels   []*metapb.StoreLabel `json:"labels,omitempty"`
+}
+
+// HotRegionFactors : the factors of the hot region scheduler, both in (0, 1].
+// The source store is balanced only if the flow of the destination store is
+// below ScheduleFactor of it, and LimitFactor scales the operator limit
+// adjusted by the surplus of the source store.
+type HotRegionFactors struct {
+	ScheduleFactor float64 `json:"schedule-factor"`
+	LimitFactor    float64 `json:"limit-factor"`
+}
+
+// HotRegionDailyReport : the hot region moves of a day.
+type HotRegionDailyReport struct {
+	// Date is in "2006-01-02" format.
+	Date string `json:"date"`
+	// Stores are sorted by the store ID, it is omitted if no store is
+	// moved in the days of the report.
+	Stores []HotRegionStoreReport `json:"stores,omitempty"`
+}
+
+// HotRegionStoreReport : the hot region moves into and out of a store.
+type HotRegionStoreReport struct {
+	StoreID       uint64 `json:"store_id"`
+	InMoves       int    `json:"in_moves"`
+	OutMoves      int    `json:"out_moves"`
+	InRegionSize  int64  `json:"in_region_size"`
+	OutRegionSize int64  `json:"out_region_size"`
+	InFlowBytes   uint64 `json:"in_flow_bytes"`
+	OutFlowBytes  uint64 `json:"out_flow_bytes"`
+}
+
+// LeaderLabelConstraint : the label of the stores preferred to hold hot leaders.
+// If Strict is false, the other stores are still considered when none of
+// the candidates match the label.
+type LeaderLabelConstraint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Strict bool   `json:"strict"`
 }
 
 // StoreHotRegionsStat used to record the hot region statistics group by store
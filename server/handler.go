@@ -15,6 +15,8 @@ package server
 
 import (
 	"bytes"
+	"io"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -35,6 +37,8 @@ var (
 	ErrOperatorNotFound = errors.New("operator not found")
 	// ErrAddOperator is error info for already have an operator when adding operator
 	ErrAddOperator = errors.New("failed to add operator, maybe already have one")
+	// ErrSchedulerNotSupportHTTP is error info for scheduler not serving its config by HTTP
+	ErrSchedulerNotSupportHTTP = errors.New("scheduler does not support HTTP")
 	// ErrRegionNotAdjacent is error info for region not adjacent
 	ErrRegionNotAdjacent = errors.New("two regions are not adjacent")
 	// ErrRegionNotFound is error info for region not found
@@ -80,6 +84,125 @@ func (h *Handler) GetSchedulers() ([]string, error) {
 	return c.getSchedulers(), nil
 }
 
+// GetScheduleRounds returns the summary of the latest scheduling rounds.
+func (h *Handler) GetScheduleRounds() ([]*ScheduleRound, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, err
+	}
+	return c.getScheduleRounds(), nil
+}
+
+// GetHotRegionHistory returns at most limit latest decisions of the hot
+// region scheduler, from the latest to the oldest.
+func (h *Handler) GetHotRegionHistory(limit int) ([]core.HotRegionDecision, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, err
+	}
+	return c.getHotRegionHistory(limit), nil
+}
+
+// GetHotRegionDailyReport returns the hot region moves per day and per store
+// of the latest days.
+func (h *Handler) GetHotRegionDailyReport(days int) ([]core.HotRegionDailyReport, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, err
+	}
+	return c.getHotRegionDailyReport(days), nil
+}
+
+// GetHotSchedulerChanges returns at most limit latest changes of the tunable
+// values of the hot region scheduler, from the latest to the oldest.
+func (h *Handler) GetHotSchedulerChanges(limit int) ([]core.HotSchedulerChange, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, err
+	}
+	return c.getHotSchedulerChanges(limit), nil
+}
+
+// WriteHotSupportBundle writes the internal state of the hot region
+// scheduler for debugging.
+func (h *Handler) WriteHotSupportBundle(w io.Writer) error {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return err
+	}
+	return c.writeHotSupportBundle(w)
+}
+
+// GetHotStoreExclusion returns the stores excluded from the hot region
+// scheduling.
+func (h *Handler) GetHotStoreExclusion() (core.HotStoreExclusion, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return core.HotStoreExclusion{}, err
+	}
+	return c.getHotStoreExclusion()
+}
+
+// SetHotStoreExclusion replaces the stores excluded from the hot region
+// scheduling.
+func (h *Handler) SetHotStoreExclusion(cfg core.HotStoreExclusion) error {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return err
+	}
+	return c.setHotStoreExclusion(cfg)
+}
+
+// AddHotTargetBlacklist stops the hot region scheduler moving the hot peers
+// and leaders to the store.
+func (h *Handler) AddHotTargetBlacklist(storeID uint64) error {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return err
+	}
+	return c.setHotTargetBlacklist(storeID, true)
+}
+
+// RemoveHotTargetBlacklist lets the hot region scheduler move the hot peers
+// and leaders to the store again.
+func (h *Handler) RemoveHotTargetBlacklist(storeID uint64) error {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return err
+	}
+	return c.setHotTargetBlacklist(storeID, false)
+}
+
+// SetHotBalanceTypes changes the balance types of the hot region scheduler,
+// e.g. "hot-read", "hot-write" and "hot-keys".
+func (h *Handler) SetHotBalanceTypes(names []string) error {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return err
+	}
+	return c.setHotBalanceTypes(names)
+}
+
+// SetHotDryRun enables or disables the dry run of the hot region scheduler,
+// which logs the operators instead of executing them.
+func (h *Handler) SetHotDryRun(dryRun bool) error {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return err
+	}
+	return c.setHotDryRun(dryRun)
+}
+
+// GetSchedulerHandler returns the HTTP handler of the running scheduler with
+// the name, which serves the config of the scheduler.
+func (h *Handler) GetSchedulerHandler(name string) (http.Handler, error) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, err
+	}
+	return c.getSchedulerHandler(name)
+}
+
 // GetStores returns all stores in the cluster.
 func (h *Handler) GetStores() ([]*core.StoreInfo, error) {
 	cluster := h.s.GetRaftCluster()
@@ -116,6 +239,16 @@ func (h *Handler) GetHotReadRegions() *core.StoreHotRegionInfos {
 	return c.getHotReadRegions()
 }
 
+// GetHotRegions gets all hot read and write regions stats at once, so they
+// are consistent with each other.
+func (h *Handler) GetHotRegions() (read, write *core.StoreHotRegionInfos) {
+	c, err := h.getCoordinator()
+	if err != nil {
+		return nil, nil
+	}
+	return c.getHotRegions()
+}
+
 // GetHotBytesWriteStores gets all hot write stores stats.
 func (h *Handler) GetHotBytesWriteStores() map[uint64]uint64 {
 	cluster := h.s.GetRaftCluster()
@@ -15,6 +15,7 @@ package server
 
 import (
 	"reflect"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/schedule"
+	"github.com/pkg/errors"
 )
 
 // scheduleOption is a wrapper to access the configuration safely.
@@ -144,6 +146,18 @@ func (o *scheduleOption) GetHighSpaceRatio() float64 {
 	return o.load().HighSpaceRatio
 }
 
+func (o *scheduleOption) GetHotRegionModelURL() string {
+	return o.load().HotRegionModelURL
+}
+
+func (o *scheduleOption) IsHotRegionModelDisabled() bool {
+	return o.load().DisableHotRegionModel
+}
+
+func (o *scheduleOption) GetHotRegionMinFlowBytes() uint64 {
+	return o.load().HotRegionMinFlowBytes
+}
+
 func (o *scheduleOption) IsRaftLearnerEnabled() bool {
 	return !o.load().DisableLearner
 }
@@ -203,6 +217,11 @@ func (o *scheduleOption) RemoveSchedulerCfg(name string) error {
 	c := o.load()
 	v := c.clone()
 	for i, schedulerCfg := range v.Schedulers {
+		// The disabled entry of a default scheduler may have the same name as
+		// the enabled one whose arguments are changed.
+		if schedulerCfg.Disable {
+			continue
+		}
 		// To create a temporary scheduler is just used to get scheduler's name
 		tmp, err := schedule.CreateScheduler(schedulerCfg.Type, schedule.NewOperatorController(nil, nil), schedulerCfg.Args...)
 		if err != nil {
@@ -222,6 +241,71 @@ func (o *scheduleOption) RemoveSchedulerCfg(name string) error {
 	return nil
 }
 
+// SetSchedulerArgs sets the "key=value" arguments of the enabled scheduler
+// with the name, replacing the ones with the same keys, so that the scheduler
+// is restored with the arguments after restarting. Like removing, the entry
+// of a default scheduler is disabled instead of changed, and the scheduler
+// is restored from a new entry with the arguments.
+func (o *scheduleOption) SetSchedulerArgs(name string, args ...string) error {
+	c := o.load()
+	v := c.clone()
+	for i, schedulerCfg := range v.Schedulers {
+		if schedulerCfg.Disable {
+			continue
+		}
+		// To create a temporary scheduler is just used to get scheduler's name
+		tmp, err := schedule.CreateScheduler(schedulerCfg.Type, schedule.NewOperatorController(nil, nil), schedulerCfg.Args...)
+		if err != nil {
+			return err
+		}
+		if tmp.GetName() != name {
+			continue
+		}
+		newArgs := mergeSchedulerArgs(schedulerCfg.Args, args)
+		if isDefaultSchedulerCfg(schedulerCfg) {
+			v.Schedulers[i].Disable = true
+			v.Schedulers = append(v.Schedulers, SchedulerConfig{Type: schedulerCfg.Type, Args: newArgs})
+		} else {
+			v.Schedulers[i].Args = newArgs
+		}
+		o.store(v)
+		return nil
+	}
+	return errors.Errorf("scheduler %s not found", name)
+}
+
+// mergeSchedulerArgs returns the "key=value" arguments with the ones of the
+// same keys replaced by the changes, and the other changes appended.
+func mergeSchedulerArgs(args, changes []string) []string {
+	merged := make([]string, 0, len(args)+len(changes))
+	merged = append(merged, args...)
+	for _, change := range changes {
+		key := strings.SplitN(change, "=", 2)[0]
+		replaced := false
+		for i, arg := range merged {
+			if strings.SplitN(arg, "=", 2)[0] == key {
+				merged[i], replaced = change, true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, change)
+		}
+	}
+	return merged
+}
+
+// isDefaultSchedulerCfg returns true if the entry is one of the default
+// schedulers, which is matched by the persisted one when reloading.
+func isDefaultSchedulerCfg(cfg SchedulerConfig) bool {
+	for _, d := range defaultSchedulers {
+		if cfg.Type == d.Type && reflect.DeepEqual(cfg.Args, d.Args) {
+			return true
+		}
+	}
+	return false
+}
+
 func (o *scheduleOption) SetLabelProperty(typ, labelKey, labelValue string) {
 	cfg := o.loadLabelPropertyConfig().clone()
 	for _, l := range cfg[typ] {
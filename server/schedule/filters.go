@@ -15,6 +15,9 @@ package schedule
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/pingcap/pd/server/cache"
 	"github.com/pingcap/pd/server/core"
@@ -59,6 +62,90 @@ func FilterTarget(opt Options, store *core.StoreInfo, filters []Filter) bool {
 	return false
 }
 
+// FilterStats records how many times the stores are rejected by each filter,
+// grouped by filter type and store ID.
+type FilterStats map[string]map[uint64]uint64
+
+// Add records a rejection of the store by the filter.
+func (s FilterStats) Add(filterType string, storeID uint64) {
+	stores, ok := s[filterType]
+	if !ok {
+		stores = make(map[uint64]uint64)
+		s[filterType] = stores
+	}
+	stores[storeID]++
+}
+
+// Merge adds the rejections recorded in other.
+func (s FilterStats) Merge(other FilterStats) {
+	for filterType, stores := range other {
+		for storeID, count := range stores {
+			if _, ok := s[filterType]; !ok {
+				s[filterType] = make(map[uint64]uint64)
+			}
+			s[filterType][storeID] += count
+		}
+	}
+}
+
+func (s FilterStats) String() string {
+	var items []string
+	for filterType, stores := range s {
+		for storeID, count := range stores {
+			items = append(items, fmt.Sprintf("%s/store%d=%d", filterType, storeID, count))
+		}
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+var filterRejections = struct {
+	sync.RWMutex
+	// scheduler name -> filter rejections
+	stats map[string]FilterStats
+}{stats: make(map[string]FilterStats)}
+
+// FilterTargetCounted checks if store can pass all Filters as target store
+// like FilterTarget. The rejection is recorded for the scheduler, and is
+// added to stats if it is not nil.
+func FilterTargetCounted(opt Options, store *core.StoreInfo, filters []Filter, scheduler string, stats FilterStats) bool {
+	storeID := fmt.Sprintf("store%d", store.GetId())
+	for _, filter := range filters {
+		if filter.FilterTarget(opt, store) {
+			log.Debugf("[filter %T] filters store %v from target", filter, store)
+			filterCounter.WithLabelValues("filter-target", storeID, filter.Type()).Inc()
+			filterRejectionCounter.WithLabelValues(scheduler, filter.Type(), storeID).Inc()
+			if stats != nil {
+				stats.Add(filter.Type(), store.GetId())
+			}
+			filterRejections.Lock()
+			schedulerStats, ok := filterRejections.stats[scheduler]
+			if !ok {
+				schedulerStats = make(FilterStats)
+				filterRejections.stats[scheduler] = schedulerStats
+			}
+			schedulerStats.Add(filter.Type(), store.GetId())
+			filterRejections.Unlock()
+			return true
+		}
+	}
+	return false
+}
+
+// GetFilterRejections returns the filter rejections recorded by
+// FilterTargetCounted, grouped by scheduler name.
+func GetFilterRejections() map[string]FilterStats {
+	filterRejections.RLock()
+	defer filterRejections.RUnlock()
+	rejections := make(map[string]FilterStats, len(filterRejections.stats))
+	for scheduler, stats := range filterRejections.stats {
+		clone := make(FilterStats, len(stats))
+		clone.Merge(stats)
+		rejections[scheduler] = clone
+	}
+	return rejections
+}
+
 type excludedFilter struct {
 	sources map[uint64]struct{}
 	targets map[uint64]struct{}
@@ -330,6 +417,31 @@ func (f *namespaceFilter) FilterTarget(opt Options, store *core.StoreInfo) bool
 	return f.filter(store)
 }
 
+type applyLoadFilter struct {
+	provider  ApplyLoadProvider
+	threshold float64
+}
+
+// NewApplyLoadFilter creates a Filter that filters the target stores whose
+// apply pools are saturated above the threshold, the stores with unknown
+// saturation are not filtered.
+func NewApplyLoadFilter(provider ApplyLoadProvider, threshold float64) Filter {
+	return &applyLoadFilter{provider: provider, threshold: threshold}
+}
+
+func (f *applyLoadFilter) Type() string {
+	return "apply-load-filter"
+}
+
+func (f *applyLoadFilter) FilterSource(opt Options, store *core.StoreInfo) bool {
+	return false
+}
+
+func (f *applyLoadFilter) FilterTarget(opt Options, store *core.StoreInfo) bool {
+	load, ok := f.provider.GetStoreApplyLoad(store.GetId())
+	return ok && load > f.threshold
+}
+
 type rejectLeaderFilter struct{}
 
 // NewRejectLeaderFilter creates a Filter that filters stores that marked as
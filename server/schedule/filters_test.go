@@ -36,3 +36,33 @@ func (s *testReplicationSuite) TestPendingPeerFilter(c *C) {
 	c.Assert(filter.FilterSource(tc, store), IsFalse)
 	c.Assert(filter.FilterTarget(tc, store), IsFalse)
 }
+
+func (s *testFiltersSuite) TestFilterTargetCounted(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	for id := uint64(1); id <= 4; id++ {
+		tc.AddRegionStore(id, 1)
+	}
+	tc.SetStoreOffline(2)
+	tc.SetStoreBusy(3, true)
+	filters := []Filter{
+		NewExcludedFilter(nil, map[uint64]struct{}{1: {}}),
+		StoreStateFilter{MoveRegion: true},
+	}
+
+	stats := make(FilterStats)
+	for id := uint64(1); id <= 4; id++ {
+		c.Assert(FilterTargetCounted(tc, tc.GetStore(id), filters, "test-filter-counted", stats), Equals, id != 4)
+	}
+	expected := FilterStats{
+		"exclude-filter":     {1: 1},
+		"store-state-filter": {2: 1, 3: 1},
+	}
+	c.Assert(stats, DeepEquals, expected)
+	c.Assert(stats.String(), Equals, "exclude-filter/store1=1,store-state-filter/store2=1,store-state-filter/store3=1")
+	c.Assert(GetFilterRejections()["test-filter-counted"], DeepEquals, expected)
+
+	// The rejections are accumulated across calls.
+	c.Assert(FilterTargetCounted(tc, tc.GetStore(1), filters, "test-filter-counted", nil), IsTrue)
+	c.Assert(GetFilterRejections()["test-filter-counted"]["exclude-filter"][1], Equals, uint64(2))
+}
@@ -47,10 +47,12 @@ func newHotSpotCache() *HotSpotCache {
 func (w *HotSpotCache) CheckWrite(region *core.RegionInfo, stores *core.StoresInfo) (bool, *core.RegionStat) {
 	var (
 		WrittenBytesPerSec uint64
+		WrittenKeysPerSec  uint64
 		value              *core.RegionStat
 	)
 
 	WrittenBytesPerSec = uint64(float64(region.GetBytesWritten()) / float64(RegionHeartBeatReportInterval))
+	WrittenKeysPerSec = uint64(float64(region.GetKeysWritten()) / float64(RegionHeartBeatReportInterval))
 
 	v, isExist := w.writeFlow.Peek(region.GetID())
 	if isExist {
@@ -61,21 +63,24 @@ func (w *HotSpotCache) CheckWrite(region *core.RegionInfo, stores *core.StoresIn
 				return false, nil
 			}
 			WrittenBytesPerSec = uint64(float64(region.GetBytesWritten()) / interval)
+			WrittenKeysPerSec = uint64(float64(region.GetKeysWritten()) / interval)
 		}
 	}
 
 	hotRegionThreshold := calculateWriteHotThreshold(stores)
-	return w.isNeedUpdateStatCache(region, WrittenBytesPerSec, hotRegionThreshold, value, WriteFlow)
+	return w.isNeedUpdateStatCache(region, WrittenBytesPerSec, WrittenKeysPerSec, hotRegionThreshold, value, WriteFlow)
 }
 
 // CheckRead checks the read status, returns whether need update statistics and item.
 func (w *HotSpotCache) CheckRead(region *core.RegionInfo, stores *core.StoresInfo) (bool, *core.RegionStat) {
 	var (
 		ReadBytesPerSec uint64
+		ReadKeysPerSec  uint64
 		value           *core.RegionStat
 	)
 
 	ReadBytesPerSec = uint64(float64(region.GetBytesRead()) / float64(RegionHeartBeatReportInterval))
+	ReadKeysPerSec = uint64(float64(region.GetKeysRead()) / float64(RegionHeartBeatReportInterval))
 
 	v, isExist := w.readFlow.Peek(region.GetID())
 	if isExist {
@@ -86,11 +91,12 @@ func (w *HotSpotCache) CheckRead(region *core.RegionInfo, stores *core.StoresInf
 				return false, nil
 			}
 			ReadBytesPerSec = uint64(float64(region.GetBytesRead()) / interval)
+			ReadKeysPerSec = uint64(float64(region.GetKeysRead()) / interval)
 		}
 	}
 
 	hotRegionThreshold := calculateReadHotThreshold(stores)
-	return w.isNeedUpdateStatCache(region, ReadBytesPerSec, hotRegionThreshold, value, ReadFlow)
+	return w.isNeedUpdateStatCache(region, ReadBytesPerSec, ReadKeysPerSec, hotRegionThreshold, value, ReadFlow)
 }
 
 func (w *HotSpotCache) incMetrics(name string, kind FlowKind) {
@@ -117,11 +123,27 @@ func calculateWriteHotThreshold(stores *core.StoresInfo) uint64 {
 }
 
 func calculateReadHotThreshold(stores *core.StoresInfo) uint64 {
+	return readHotThreshold(stores.TotalBytesReadRate())
+}
+
+// ReadHotRegionThreshold returns the read bytes rate above which a region is
+// hot, computed from the stores the same way as the hot cache does.
+func ReadHotRegionThreshold(stores []*core.StoreInfo) uint64 {
+	var totalBytesReadRate float64
+	for _, s := range stores {
+		if s.IsUp() {
+			totalBytesReadRate += s.RollingStoreStats.GetBytesReadRate()
+		}
+	}
+	return readHotThreshold(totalBytesReadRate)
+}
+
+func readHotThreshold(totalBytesReadRate float64) uint64 {
 	// hotRegionThreshold is use to pick hot region
 	// suppose the number of the hot Regions is statLRUMaxLen
 	// and we use total Read Bytes past storeHeartBeatReportInterval seconds to divide the number of hot Regions
 	divisor := float64(statCacheMaxLen)
-	hotRegionThreshold := uint64(stores.TotalBytesReadRate() / divisor)
+	hotRegionThreshold := uint64(totalBytesReadRate / divisor)
 
 	if hotRegionThreshold < hotReadRegionMinFlowRate {
 		hotRegionThreshold = hotReadRegionMinFlowRate
@@ -131,8 +153,11 @@ func calculateReadHotThreshold(stores *core.StoresInfo) uint64 {
 
 const rollingWindowsSize = 5
 
-func (w *HotSpotCache) isNeedUpdateStatCache(region *core.RegionInfo, flowBytes uint64, hotRegionThreshold uint64, oldItem *core.RegionStat, kind FlowKind) (bool, *core.RegionStat) {
+// isNeedUpdateStatCache decides whether the region is hot by the flow bytes,
+// the flow keys are only recorded.
+func (w *HotSpotCache) isNeedUpdateStatCache(region *core.RegionInfo, flowBytes, flowKeys uint64, hotRegionThreshold uint64, oldItem *core.RegionStat, kind FlowKind) (bool, *core.RegionStat) {
 	newItem := core.NewRegionStat(region, flowBytes, hotRegionAntiCount)
+	newItem.FlowKeys = flowKeys
 	if oldItem != nil {
 		newItem.HotDegree = oldItem.HotDegree + 1
 		newItem.Stats = oldItem.Stats
@@ -49,6 +49,14 @@ var (
 			Help:      "Counter of the filter",
 		}, []string{"action", "store", "type"})
 
+	filterRejectionCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pd",
+			Subsystem: "schedule",
+			Name:      "filter_rejection",
+			Help:      "Counter of the stores rejected by the filters of schedulers.",
+		}, []string{"scheduler", "filter", "store"})
+
 	operatorCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Namespace: "pd",
@@ -72,6 +80,7 @@ func init() {
 	prometheus.MustRegister(operatorStepDuration)
 	prometheus.MustRegister(hotCacheStatusGauge)
 	prometheus.MustRegister(filterCounter)
+	prometheus.MustRegister(filterRejectionCounter)
 	prometheus.MustRegister(operatorCounter)
 	prometheus.MustRegister(operatorDuration)
 }
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/kvproto/pkg/pdpb"
 	"github.com/pingcap/pd/server/core"
@@ -31,6 +32,10 @@ type MockCluster struct {
 	*core.MockIDAllocator
 	*MockSchedulerOptions
 	ID uint64
+
+	writeAmplification map[uint64]float64
+	applyLoad          map[uint64]float64
+	clusterVersion     semver.Version
 }
 
 // NewMockCluster creates a new MockCluster
@@ -39,9 +44,23 @@ func NewMockCluster(opt *MockSchedulerOptions) *MockCluster {
 		BasicCluster:         NewBasicCluster(),
 		MockIDAllocator:      core.NewMockIDAllocator(),
 		MockSchedulerOptions: opt,
+		writeAmplification:   make(map[uint64]float64),
+		applyLoad:            make(map[uint64]float64),
+		// All the features are supported by default.
+		clusterVersion: semver.Version{Major: 3},
 	}
 }
 
+// GetClusterVersion returns the version of the cluster.
+func (mc *MockCluster) GetClusterVersion() semver.Version {
+	return mc.clusterVersion
+}
+
+// SetClusterVersion sets the version of the cluster.
+func (mc *MockCluster) SetClusterVersion(v semver.Version) {
+	mc.clusterVersion = v
+}
+
 func (mc *MockCluster) allocID() (uint64, error) {
 	return mc.Alloc()
 }
@@ -178,8 +197,13 @@ func (mc *MockCluster) AddLeaderRegionWithRange(regionID uint64, startKey string
 
 // AddLeaderRegionWithReadInfo adds region with specified leader, followers and read info.
 func (mc *MockCluster) AddLeaderRegionWithReadInfo(regionID uint64, leaderID uint64, readBytes uint64, followerIds ...uint64) {
+	mc.AddLeaderRegionWithReadKeysInfo(regionID, leaderID, readBytes, 0, followerIds...)
+}
+
+// AddLeaderRegionWithReadKeysInfo adds region with specified leader, followers and read info including the read keys.
+func (mc *MockCluster) AddLeaderRegionWithReadKeysInfo(regionID uint64, leaderID uint64, readBytes, readKeys uint64, followerIds ...uint64) {
 	r := mc.newMockRegionInfo(regionID, leaderID, followerIds...)
-	r = r.Clone(core.SetReadBytes(readBytes))
+	r = r.Clone(core.SetReadBytes(readBytes), core.SetReadKeys(readKeys))
 	isUpdate, item := mc.BasicCluster.CheckReadStatus(r)
 	if isUpdate {
 		mc.HotCache.Update(regionID, item, ReadFlow)
@@ -189,8 +213,13 @@ func (mc *MockCluster) AddLeaderRegionWithReadInfo(regionID uint64, leaderID uin
 
 // AddLeaderRegionWithWriteInfo adds region with specified leader, followers and write info.
 func (mc *MockCluster) AddLeaderRegionWithWriteInfo(regionID uint64, leaderID uint64, writtenBytes uint64, followerIds ...uint64) {
+	mc.AddLeaderRegionWithWriteKeysInfo(regionID, leaderID, writtenBytes, 0, followerIds...)
+}
+
+// AddLeaderRegionWithWriteKeysInfo adds region with specified leader, followers and write info including the written keys.
+func (mc *MockCluster) AddLeaderRegionWithWriteKeysInfo(regionID uint64, leaderID uint64, writtenBytes, writtenKeys uint64, followerIds ...uint64) {
 	r := mc.newMockRegionInfo(regionID, leaderID, followerIds...)
-	r = r.Clone(core.SetWrittenBytes(writtenBytes))
+	r = r.Clone(core.SetWrittenBytes(writtenBytes), core.SetWrittenKeys(writtenKeys))
 	isUpdate, item := mc.BasicCluster.CheckWriteStatus(r)
 	if isUpdate {
 		mc.HotCache.Update(regionID, item, WriteFlow)
@@ -287,6 +316,28 @@ func (mc *MockCluster) UpdateStorageReadBytes(storeID uint64, BytesRead uint64)
 	mc.PutStore(store)
 }
 
+// UpdateStoreWriteAmplification updates the estimated write amplification of the store.
+func (mc *MockCluster) UpdateStoreWriteAmplification(storeID uint64, amplification float64) {
+	mc.writeAmplification[storeID] = amplification
+}
+
+// GetStoreWriteAmplification returns the estimated write amplification of the store.
+func (mc *MockCluster) GetStoreWriteAmplification(storeID uint64) (float64, bool) {
+	amplification, ok := mc.writeAmplification[storeID]
+	return amplification, ok
+}
+
+// UpdateStoreApplyLoad updates the saturation of the apply pool of the store.
+func (mc *MockCluster) UpdateStoreApplyLoad(storeID uint64, load float64) {
+	mc.applyLoad[storeID] = load
+}
+
+// GetStoreApplyLoad returns the saturation of the apply pool of the store.
+func (mc *MockCluster) GetStoreApplyLoad(storeID uint64) (float64, bool) {
+	load, ok := mc.applyLoad[storeID]
+	return load, ok
+}
+
 // UpdateStoreStatus updates store status.
 func (mc *MockCluster) UpdateStoreStatus(id uint64) {
 	mc.Stores.SetLeaderCount(id, mc.Regions.GetStoreLeaderCount(id))
@@ -452,6 +503,9 @@ type MockSchedulerOptions struct {
 	TolerantSizeRatio            float64
 	LowSpaceRatio                float64
 	HighSpaceRatio               float64
+	HotRegionModelURL            string
+	DisableHotRegionModel        bool
+	HotRegionMinFlowBytes        uint64
 	DisableLearner               bool
 	DisableRemoveDownReplica     bool
 	DisableReplaceOfflineReplica bool
@@ -563,6 +617,21 @@ func (mso *MockSchedulerOptions) GetHighSpaceRatio() float64 {
 	return mso.HighSpaceRatio
 }
 
+// GetHotRegionModelURL mock method
+func (mso *MockSchedulerOptions) GetHotRegionModelURL() string {
+	return mso.HotRegionModelURL
+}
+
+// IsHotRegionModelDisabled mock method
+func (mso *MockSchedulerOptions) IsHotRegionModelDisabled() bool {
+	return mso.DisableHotRegionModel
+}
+
+// GetHotRegionMinFlowBytes mock method
+func (mso *MockSchedulerOptions) GetHotRegionMinFlowBytes() uint64 {
+	return mso.HotRegionMinFlowBytes
+}
+
 // SetMaxReplicas mock method
 func (mso *MockSchedulerOptions) SetMaxReplicas(replicas int) {
 	mso.MaxReplicas = replicas
@@ -238,6 +238,7 @@ func (sr SplitRegion) Influence(opInfluence OpInfluence, region *core.RegionInfo
 // Operator contains execution steps generated by scheduler.
 type Operator struct {
 	desc        string
+	detail      string
 	regionID    uint64
 	regionEpoch *metapb.RegionEpoch
 	kind        OperatorKind
@@ -246,6 +247,7 @@ type Operator struct {
 	createTime  time.Time
 	stepTime    int64
 	level       core.PriorityLevel
+	tags        map[string]string
 }
 
 // NewOperator creates a new operator.
@@ -263,7 +265,7 @@ func NewOperator(desc string, regionID uint64, regionEpoch *metapb.RegionEpoch,
 }
 
 func (o *Operator) String() string {
-	s := fmt.Sprintf("%s (kind:%s, region:%v(%v,%v), createAt:%s, currentStep:%v, steps:%+v) ", o.desc, o.kind, o.regionID, o.regionEpoch.GetVersion(), o.regionEpoch.GetConfVer(), o.createTime, atomic.LoadInt32(&o.currentStep), o.steps)
+	s := fmt.Sprintf("%s%s (kind:%s, region:%v(%v,%v), createAt:%s, currentStep:%v, steps:%+v) ", o.desc, o.detail, o.kind, o.regionID, o.regionEpoch.GetVersion(), o.regionEpoch.GetConfVer(), o.createTime, atomic.LoadInt32(&o.currentStep), o.steps)
 	if o.IsTimeout() {
 		s = s + "timeout"
 	}
@@ -288,6 +290,17 @@ func (o *Operator) SetDesc(desc string) {
 	o.desc = desc
 }
 
+// Detail returns the operator's detail, which follows the description in the
+// operator log. Unlike the description, it is not used as a metrics label.
+func (o *Operator) Detail() string {
+	return o.detail
+}
+
+// SetDetail sets the detail for the operator.
+func (o *Operator) SetDetail(detail string) {
+	o.detail = detail
+}
+
 // AttachKind attaches an operator kind for the operator.
 func (o *Operator) AttachKind(kind OperatorKind) {
 	o.kind |= kind
@@ -352,6 +365,31 @@ func (o *Operator) GetPriorityLevel() core.PriorityLevel {
 	return o.level
 }
 
+// SetTag attaches a key/value tag to the operator. Tags are set by the
+// scheduler which creates the operator, so that downstream policies can make
+// decisions based on them.
+func (o *Operator) SetTag(key, value string) {
+	if o.tags == nil {
+		o.tags = make(map[string]string)
+	}
+	o.tags[key] = value
+}
+
+// GetTag returns the value of the tag and whether it is attached.
+func (o *Operator) GetTag(key string) (string, bool) {
+	value, ok := o.tags[key]
+	return value, ok
+}
+
+// GetTags returns a copy of all tags attached to the operator.
+func (o *Operator) GetTags() map[string]string {
+	tags := make(map[string]string, len(o.tags))
+	for k, v := range o.tags {
+		tags[k] = v
+	}
+	return tags
+}
+
 // IsFinish checks if all steps are finished.
 func (o *Operator) IsFinish() bool {
 	return atomic.LoadInt32(&o.currentStep) >= int32(len(o.steps))
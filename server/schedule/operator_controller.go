@@ -100,8 +100,11 @@ func (oc *OperatorController) checkAddOperator(op *Operator) bool {
 		log.Debugf("[region %v] region not found, cancel add operator", op.RegionID())
 		return false
 	}
+	// The epoch is captured when the operator is created, it is stale if the
+	// region has changed before the submission.
 	if region.GetRegionEpoch().GetVersion() != op.RegionEpoch().GetVersion() || region.GetRegionEpoch().GetConfVer() != op.RegionEpoch().GetConfVer() {
 		log.Debugf("[region %v] region epoch not match, %v vs %v, cancel add operator", op.RegionID(), region.GetRegionEpoch(), op.RegionEpoch())
+		operatorCounter.WithLabelValues(op.Desc(), "stale_epoch_at_submit").Inc()
 		return false
 	}
 	if old := oc.operators[op.RegionID()]; old != nil && !isHigherPriorityOperator(op, old) {
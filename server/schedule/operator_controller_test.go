@@ -18,6 +18,8 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var _ = Suite(&testOperatorControllerSuite{})
@@ -51,3 +53,49 @@ func (t *testOperatorControllerSuite) TestGetOpInfluence(c *C) {
 	time.Sleep(1 * time.Second)
 	c.Assert(oc.GetOperator(2), NotNil)
 }
+
+func (t *testOperatorControllerSuite) TestStaleEpochAtSubmit(c *C) {
+	opt := NewMockSchedulerOptions()
+	tc := NewMockCluster(opt)
+	oc := NewOperatorController(tc, NewMockHeartbeatStreams(tc.ID))
+	tc.AddLeaderStore(1, 1)
+	tc.AddLeaderStore(2, 0)
+	tc.AddLeaderRegion(1, 1, 2)
+	region := tc.GetRegion(1)
+	op := NewOperator("test", 1, region.GetRegionEpoch(), OpLeader, TransferLeader{FromStore: 1, ToStore: 2})
+
+	// The region splits after the operator is created.
+	before := operatorEventCount(c, "test", "stale_epoch_at_submit")
+	tc.PutRegion(region.Clone(core.SetRegionVersion(region.GetRegionEpoch().GetVersion() + 1)))
+	c.Assert(oc.AddOperator(op), IsFalse)
+	c.Assert(oc.GetOperator(1), IsNil)
+	c.Assert(operatorEventCount(c, "test", "stale_epoch_at_submit"), Equals, before+1)
+
+	// Recreated with the current epoch.
+	op = NewOperator("test", 1, tc.GetRegion(1).GetRegionEpoch(), OpLeader, TransferLeader{FromStore: 1, ToStore: 2})
+	c.Assert(oc.AddOperator(op), IsTrue)
+	c.Assert(oc.GetOperator(1), Equals, op)
+	c.Assert(operatorEventCount(c, "test", "stale_epoch_at_submit"), Equals, before+1)
+}
+
+// operatorEventCount returns the value of the operator counter with the type
+// and the event.
+func operatorEventCount(c *C, typ, event string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	c.Assert(err, IsNil)
+	for _, family := range families {
+		if family.GetName() != "pd_schedule_operators_count" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+			if labels["type"] == typ && labels["event"] == event {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
@@ -15,6 +15,7 @@ package schedule
 
 import (
 	"encoding/json"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -110,6 +111,41 @@ func (s *testOperatorSuite) TestOperator(c *C) {
 	c.Assert(op.IsTimeout(), IsTrue)
 }
 
+func (s *testOperatorSuite) TestOperatorTags(c *C) {
+	op := s.newTestOperator(1, OpLeader, TransferLeader{FromStore: 2, ToStore: 1})
+	_, ok := op.GetTag("reason")
+	c.Assert(ok, IsFalse)
+	c.Assert(op.GetTags(), HasLen, 0)
+
+	op.SetTag("reason", "hot")
+	op.SetTag("dimension", "flow-bytes")
+	value, ok := op.GetTag("reason")
+	c.Assert(ok, IsTrue)
+	c.Assert(value, Equals, "hot")
+
+	// GetTags returns a copy, modifying it doesn't affect the operator.
+	tags := op.GetTags()
+	c.Assert(tags, DeepEquals, map[string]string{"reason": "hot", "dimension": "flow-bytes"})
+	tags["reason"] = "cold"
+	value, _ = op.GetTag("reason")
+	c.Assert(value, Equals, "hot")
+}
+
+func (s *testOperatorSuite) TestOperatorDetail(c *C) {
+	op := s.newTestOperator(1, OpLeader, TransferLeader{FromStore: 2, ToStore: 1})
+	op.SetDesc("transferHotReadLeader")
+	op.SetDetail("{r=1, 42MiB/s, s2→s1}")
+	c.Assert(op.Desc(), Equals, "transferHotReadLeader")
+	c.Assert(strings.HasPrefix(op.String(), "transferHotReadLeader{r=1, 42MiB/s, s2→s1} (kind:"), IsTrue)
+
+	// The operators API renders the detail intact.
+	data, err := json.Marshal(op)
+	c.Assert(err, IsNil)
+	var rendered string
+	c.Assert(json.Unmarshal(data, &rendered), IsNil)
+	c.Assert(rendered, Equals, op.String())
+}
+
 func (s *testOperatorSuite) TestInfluence(c *C) {
 	region := s.newTestRegion(1, 1, [2]uint64{1, 1}, [2]uint64{2, 2})
 	opInfluence := OpInfluence{storesInfluence: make(map[uint64]*StoreInfluence)}
@@ -44,6 +44,9 @@ type Options interface {
 	GetTolerantSizeRatio() float64
 	GetLowSpaceRatio() float64
 	GetHighSpaceRatio() float64
+	GetHotRegionModelURL() string
+	IsHotRegionModelDisabled() bool
+	GetHotRegionMinFlowBytes() uint64
 
 	IsRaftLearnerEnabled() bool
 
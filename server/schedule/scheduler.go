@@ -16,6 +16,7 @@ package schedule
 import (
 	"time"
 
+	"github.com/coreos/go-semver/semver"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pkg/errors"
@@ -45,6 +46,10 @@ type Cluster interface {
 	RegionReadStats() []*core.RegionStat
 	RandHotRegionFromStore(store uint64, kind FlowKind) *core.RegionInfo
 
+	// GetClusterVersion returns the version of the cluster, the features of
+	// TiKV newer than it are not supported by all the stores.
+	GetClusterVersion() semver.Version
+
 	// get config methods
 	GetOpt() NamespaceOptions
 	Options
@@ -54,6 +59,40 @@ type Cluster interface {
 	AllocPeer(storeID uint64) (*metapb.Peer, error)
 }
 
+// WriteAmplificationProvider is an optional interface for the clusters which
+// can estimate the write amplification (compaction load) of stores.
+type WriteAmplificationProvider interface {
+	// GetStoreWriteAmplification returns the estimated write amplification of
+	// the store, the second return value is false if it is unknown.
+	GetStoreWriteAmplification(storeID uint64) (float64, bool)
+}
+
+// ApplyLoadProvider is an optional interface for the clusters which can tell
+// how saturated the raft apply pools of the stores are.
+type ApplyLoadProvider interface {
+	// GetStoreApplyLoad returns the saturation of the apply pool of the
+	// store, e.g. the CPU usage of the apply threads, where 1 means fully
+	// saturated. The second return value is false if it is unknown.
+	GetStoreApplyLoad(storeID uint64) (float64, bool)
+}
+
+// SummaryProvider is an optional interface for the schedulers which can
+// describe their last scheduling decision, it is used to build the summary
+// of the scheduling rounds.
+type SummaryProvider interface {
+	// GetSummary returns the key-value description of the last decision.
+	GetSummary() map[string]string
+}
+
+// ExpeditedScheduler is an optional interface for the schedulers which may
+// know more work remains after creating operators, e.g. the operators are
+// truncated by a limit, and want to run again soon.
+type ExpeditedScheduler interface {
+	// NeedExpeditedTick is called after the scheduler creates operators, it
+	// returns true if the scheduler asks for an expedited run.
+	NeedExpeditedTick() bool
+}
+
 // Scheduler is an interface to schedule resources.
 type Scheduler interface {
 	GetName() string
@@ -67,6 +106,13 @@ type Scheduler interface {
 	IsScheduleAllowed(cluster Cluster) bool
 }
 
+// ConfigStorage persists the configs of the schedulers by their types, it is
+// implemented by core.KV.
+type ConfigStorage interface {
+	SaveSchedulerConfig(schedulerType string, cfg interface{}) error
+	LoadSchedulerConfig(schedulerType string, cfg interface{}) (bool, error)
+}
+
 // CreateSchedulerFunc is for creating scheudler.
 type CreateSchedulerFunc func(opController *OperatorController, args []string) (Scheduler, error)
 
@@ -0,0 +1,144 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	scheduleRoundDuration = runSchedulerCheckInterval
+	scheduleRoundCapacity = 32
+
+	skipReasonNotAllowed = "not-allowed"
+)
+
+// SchedulerRoundRecord summarizes the runs of a scheduler in a round.
+type SchedulerRoundRecord struct {
+	Name string `json:"name"`
+	// Runs is the number of times the scheduler was triggered.
+	Runs int `json:"runs"`
+	// Operators is the number of operators created by the scheduler.
+	Operators int `json:"operators"`
+	// Skipped is the number of runs skipped, SkipReason tells the reason of
+	// the last one.
+	Skipped    int    `json:"skipped"`
+	SkipReason string `json:"skip_reason,omitempty"`
+	// Summary is the last decision reported by the scheduler, it is only
+	// available for the schedulers implementing schedule.SummaryProvider.
+	Summary map[string]string `json:"summary,omitempty"`
+}
+
+// ScheduleRound summarizes what the schedulers did in a period of time.
+type ScheduleRound struct {
+	Start      time.Time               `json:"start"`
+	Schedulers []*SchedulerRoundRecord `json:"schedulers"`
+}
+
+// roundCollector collects the scheduler runs into rounds of a fixed duration
+// and keeps the last rounds in a ring buffer.
+type roundCollector struct {
+	sync.Mutex
+	duration time.Duration
+	// rounds is the ring buffer of the finished rounds, next is the position
+	// of the next round to be written.
+	rounds  []*ScheduleRound
+	next    int
+	full    bool
+	start   time.Time
+	records map[string]*SchedulerRoundRecord
+}
+
+func newRoundCollector(duration time.Duration, capacity int) *roundCollector {
+	return &roundCollector{
+		duration: duration,
+		rounds:   make([]*ScheduleRound, capacity),
+		records:  make(map[string]*SchedulerRoundRecord),
+	}
+}
+
+// record adds a run of the scheduler to the current round, ops is the number
+// of created operators and skipReason is not empty if the run is skipped.
+func (c *roundCollector) record(now time.Time, name string, ops int, skipReason string, summary map[string]string) {
+	c.Lock()
+	defer c.Unlock()
+	if c.start.IsZero() {
+		c.start = now
+	} else if now.Sub(c.start) >= c.duration {
+		c.finish()
+		c.start = now
+	}
+
+	r, ok := c.records[name]
+	if !ok {
+		r = &SchedulerRoundRecord{Name: name}
+		c.records[name] = r
+	}
+	r.Runs++
+	r.Operators += ops
+	if skipReason != "" {
+		r.Skipped++
+		r.SkipReason = skipReason
+	}
+	if summary != nil {
+		r.Summary = summary
+	}
+}
+
+func (c *roundCollector) finish() {
+	c.rounds[c.next] = c.currentRound()
+	c.next = (c.next + 1) % len(c.rounds)
+	if c.next == 0 {
+		c.full = true
+	}
+	c.records = make(map[string]*SchedulerRoundRecord)
+}
+
+func (c *roundCollector) currentRound() *ScheduleRound {
+	round := &ScheduleRound{
+		Start:      c.start,
+		Schedulers: make([]*SchedulerRoundRecord, 0, len(c.records)),
+	}
+	for _, r := range c.records {
+		round.Schedulers = append(round.Schedulers, r)
+	}
+	sort.Slice(round.Schedulers, func(i, j int) bool {
+		return round.Schedulers[i].Name < round.Schedulers[j].Name
+	})
+	return round
+}
+
+// getRounds returns the finished rounds from the oldest to the latest,
+// followed by the current round if any scheduler has run in it.
+func (c *roundCollector) getRounds() []*ScheduleRound {
+	c.Lock()
+	defer c.Unlock()
+	var rounds []*ScheduleRound
+	if c.full {
+		rounds = append(rounds, c.rounds[c.next:]...)
+	}
+	rounds = append(rounds, c.rounds[:c.next]...)
+	if len(c.records) != 0 {
+		// Copy the records since the current round is still being updated.
+		current := c.currentRound()
+		for i, r := range current.Schedulers {
+			copied := *r
+			current.Schedulers[i] = &copied
+		}
+		rounds = append(rounds, current)
+	}
+	return rounds
+}
@@ -14,40 +14,62 @@
 package schedulers
 
 import (
-	"encoding/json"
+	cryptorand "crypto/rand"
+	"encoding/binary"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"math"
 	"math/rand"
-	"net/http"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/coreos/go-semver/semver"
+	gh "github.com/dustin/go-humanize"
+	"github.com/opentracing/opentracing-go"
 	"github.com/pingcap/kvproto/pkg/metapb"
 	"github.com/pingcap/pd/server/core"
 	"github.com/pingcap/pd/server/schedule"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 func init() {
 	schedule.RegisterScheduler("hot-region", func(opController *schedule.OperatorController, args []string) (schedule.Scheduler, error) {
-		return newBalanceHotRegionsScheduler(opController), nil
+		h := newBalanceHotRegionsScheduler(opController)
+		if err := h.applyArgs(args); err != nil {
+			return nil, err
+		}
+		return h, nil
 	})
 	// FIXME: remove this two schedule after the balance test move in schedulers package
 	schedule.RegisterScheduler("hot-write-region", func(opController *schedule.OperatorController, args []string) (schedule.Scheduler, error) {
-		return newBalanceHotWriteRegionsScheduler(opController), nil
+		h := newBalanceHotWriteRegionsScheduler(opController)
+		if err := h.applyArgs(args); err != nil {
+			return nil, err
+		}
+		return h, nil
 	})
 	schedule.RegisterScheduler("hot-read-region", func(opController *schedule.OperatorController, args []string) (schedule.Scheduler, error) {
-		return newBalanceHotReadRegionsScheduler(opController), nil
+		h := newBalanceHotReadRegionsScheduler(opController)
+		if err := h.applyArgs(args); err != nil {
+			return nil, err
+		}
+		return h, nil
 	})
 }
 
 const (
+	hotRegionSchedulerName    = "balance-hot-region-scheduler"
 	hotRegionLimitFactor      = 0.75
 	storeHotRegionsDefaultLen = 100
 	hotRegionScheduleFactor   = 0.9
+	// hotScanFlowRatio is the ratio of the coprocessor flow above which a
+	// hot read region is a split candidate.
+	hotScanFlowRatio = 0.6
 )
 
 // BalanceType : the perspective of balance
@@ -56,395 +78,2127 @@ type BalanceType int
 const (
 	hotWriteRegionBalance BalanceType = iota
 	hotReadRegionBalance
+	// hotKeysRegionBalance balances the read and write hot leaders on the
+	// flow keys, for the regions hot by the number of small requests.
+	hotKeysRegionBalance
+)
+
+func (t BalanceType) String() string {
+	switch t {
+	case hotWriteRegionBalance:
+		return "hot-write"
+	case hotReadRegionBalance:
+		return "hot-read"
+	case hotKeysRegionBalance:
+		return "hot-keys"
+	}
+	return "unknown"
+}
+
+// balanceTypes are all the known balance types.
+var balanceTypes = []BalanceType{hotWriteRegionBalance, hotReadRegionBalance, hotKeysRegionBalance}
+
+func isKnownBalanceType(t BalanceType) bool {
+	for _, known := range balanceTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBalanceType parses the balance type by its name, e.g. "hot-read".
+func parseBalanceType(name string) (BalanceType, error) {
+	for _, t := range balanceTypes {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+	return 0, errors.Errorf("unknown balance type %q", name)
+}
+
+// balanceMode constrains the operators the scheduler creates.
+type balanceMode int
+
+const (
+	// balanceModeBoth transfers the leaders and moves the peers.
+	balanceModeBoth balanceMode = iota
+	// balanceModeLeaderOnly only transfers the leaders, which is cheaper.
+	balanceModeLeaderOnly
+	// balanceModePeerOnly only moves the peers.
+	balanceModePeerOnly
+)
+
+var balanceModeNames = []string{"both", "leader-only", "peer-only"}
+
+func (m balanceMode) String() string {
+	if int(m) < len(balanceModeNames) {
+		return balanceModeNames[m]
+	}
+	return "unknown"
+}
+
+func parseBalanceMode(s string) (balanceMode, error) {
+	for i, name := range balanceModeNames {
+		if s == name {
+			return balanceMode(i), nil
+		}
+	}
+	return balanceModeBoth, errors.Errorf("invalid balance mode %q", s)
+}
+
+func (m balanceMode) allowLeader() bool {
+	return m != balanceModePeerOnly
+}
+
+func (m balanceMode) allowPeer() bool {
+	return m != balanceModeLeaderOnly
+}
+
+// BalanceDimension : the flow the hot regions are balanced on
+type BalanceDimension int
+
+const (
+	bytesDimension BalanceDimension = iota
+	keysDimension
+	// weightedDimension combines the flow bytes and the flow keys by the
+	// keys weight of the scheduler, see balanceHotRegionsScheduler.storeFlow.
+	weightedDimension
 )
 
+func (d BalanceDimension) String() string {
+	switch d {
+	case bytesDimension:
+		return "flow-bytes"
+	case keysDimension:
+		return "flow-keys"
+	case weightedDimension:
+		return "flow-weighted"
+	}
+	return "unknown"
+}
+
+// storeFlow returns the total flow of the hot regions of a store in the dimension.
+func (d BalanceDimension) storeFlow(stat *core.HotRegionsStat) uint64 {
+	if d == keysDimension {
+		return stat.TotalFlowKeys
+	}
+	return stat.TotalFlowBytes
+}
+
+// regionFlow returns the flow of a hot region in the dimension.
+func (d BalanceDimension) regionFlow(stat *core.RegionStat) uint64 {
+	if d == keysDimension {
+		return stat.FlowKeys
+	}
+	return stat.FlowBytes
+}
+
+// imbalance returns the ratio of the maximum store flow to the average in the
+// dimension, 0 if there is no flow.
+func (d BalanceDimension) imbalance(stats core.StoreHotRegionsStat) float64 {
+	var total, max uint64
+	for _, stat := range stats {
+		flow := d.storeFlow(stat)
+		total += flow
+		if flow > max {
+			max = flow
+		}
+	}
+	return safeDiv(float64(max)*float64(len(stats)), float64(total))
+}
+
+// selectBalanceDimension picks the dimension with the greater imbalance, the
+// flow bytes are preferred on a tie, which includes the case that the flow
+// keys are not reported.
+func selectBalanceDimension(stats core.StoreHotRegionsStat) BalanceDimension {
+	if keysDimension.imbalance(stats) > bytesDimension.imbalance(stats) {
+		return keysDimension
+	}
+	return bytesDimension
+}
+
+// bytesPerKey returns the average flow bytes per key of the stores, 0 if the
+// flow keys are not reported.
+func bytesPerKey(stats core.StoreHotRegionsStat) float64 {
+	var totalBytes, totalKeys uint64
+	for _, stat := range stats {
+		totalBytes += stat.TotalFlowBytes
+		totalKeys += stat.TotalFlowKeys
+	}
+	return safeDiv(float64(totalBytes), float64(totalKeys))
+}
+
+// Tag keys attached to the operators created by balanceHotRegionsScheduler.
+const (
+	hotTagBalanceType = "balance-type"
+	hotTagDimension   = "dimension"
+	hotTagReason      = "reason"
+	hotTagCandidates  = "candidates"
+	// hotTagFilterRejections summarizes the target stores rejected by
+	// filters in the dispatch creating the operator.
+	hotTagFilterRejections = "filter-rejections"
+	// hotTagRelaxed lists the constraints relaxed to create the operator.
+	hotTagRelaxed = "relaxed"
+	// hotTagSuspectStores lists the stores skipped as the source because
+	// their hot region flow is inconsistent with the store flow.
+	hotTagSuspectStores = "suspect-stores"
+
+	// hotSummaryLimit is only in the summary, it records the inputs of the
+	// limit adjusted for the operator.
+	hotSummaryLimit = "limit"
+	// hotSummaryDryRun is only in the summary, it is set if the operators of
+	// the dispatch are only logged.
+	hotSummaryDryRun = "dry-run"
+)
+
+// relaxableConstraints are the soft constraints which can be relaxed when a
+// critically hot source store has no destination under the strict ones. The
+// placement safety (store state, stores of the region) is never relaxed.
+var relaxableConstraints = []string{
+	// the distinct score (topology) of the region
+	"topology",
+	// the margin of hot region count and flow bytes between source and destination
+	"improvement-margin",
+}
+
+func relaxableConstraintsString() string {
+	return strings.Join(relaxableConstraints, ",")
+}
+
 type storeStatistics struct {
 	readStatAsLeader  core.StoreHotRegionsStat
 	writeStatAsPeer   core.StoreHotRegionsStat
 	writeStatAsLeader core.StoreHotRegionsStat
+	// The statistics by keys keep the regions below minRegionFlowBytes,
+	// which may still carry many keys.
+	readStatAsLeaderByKeys  core.StoreHotRegionsStat
+	writeStatAsLeaderByKeys core.StoreHotRegionsStat
+	// store id -> estimated write amplification, nil if it is not considered.
+	writeAmplification map[uint64]float64
+	// lastUpdate is the time readStatAsLeader, writeStatAsLeader and
+	// writeStatAsPeer are calculated, they are refreshed together.
+	lastUpdate time.Time
 }
 
 func newStoreStaticstics() *storeStatistics {
 	return &storeStatistics{
-		readStatAsLeader:  make(core.StoreHotRegionsStat),
-		writeStatAsLeader: make(core.StoreHotRegionsStat),
-		writeStatAsPeer:   make(core.StoreHotRegionsStat),
+		readStatAsLeader:        make(core.StoreHotRegionsStat),
+		writeStatAsLeader:       make(core.StoreHotRegionsStat),
+		writeStatAsPeer:         make(core.StoreHotRegionsStat),
+		readStatAsLeaderByKeys:  make(core.StoreHotRegionsStat),
+		writeStatAsLeaderByKeys: make(core.StoreHotRegionsStat),
 	}
 }
 
 type balanceHotRegionsScheduler struct {
 	*baseScheduler
 	sync.RWMutex
-	limit uint64
-	types []BalanceType
+	// readLimit and writeLimit are the limits of the running hot region
+	// operators when balancing the read and write flow, they are adjusted
+	// by adjustBalanceLimit independently but never below baseLimit.
+	readLimit  uint64        `tunable:"read-limit"`
+	writeLimit uint64        `tunable:"write-limit"`
+	baseLimit  uint64        `tunable:"limit"`
+	types      []BalanceType `tunable:"types"`
+	// maxLimit clamps the adjusted limit, 0 means no clamp.
+	maxLimit uint64 `tunable:"max-limit"`
+	// scheduleFactor is the ratio of the source store flow below which the
+	// destination store flow must be, limitFactor scales the surplus of the
+	// source store to the limit. Both are in (0, 1].
+	scheduleFactor float64 `tunable:"schedule-factor"`
+	limitFactor    float64 `tunable:"limit-factor"`
+	// minHotRegionCount is the minimal number of hot regions of a source
+	// store, and the destination store must have at least minHotRegionCount
+	// fewer hot regions to be chosen for its hot region count. It is at
+	// least 1, setting it to 1 lets a store with a single dominant hot region
+	// be the source, the region is still moved only if the destination
+	// doesn't become hotter than the source, e.g. by the relaxed retry.
+	minHotRegionCount int
+	// balanceType is the type of the current dispatch.
+	balanceType BalanceType
+	// dimension is the flow the current decision balances on, it is chosen
+	// by the imbalance of the stores unless pinDimension is set.
+	dimension    BalanceDimension
+	pinDimension bool
+	// keysWeight is the weight of the flow keys in [0, 1] when the stores
+	// are scored, the flow bytes take the rest. 0 keeps choosing either the
+	// bytes or the keys by the imbalance, otherwise the weighted dimension
+	// is used, in which the flow keys are converted to bytes by bytesPerKey
+	// of the current decision.
+	keysWeight  float64 `tunable:"keys-weight"`
+	bytesPerKey float64
+	// limitInputs records how the limit is adjusted in the current dispatch.
+	limitInputs string
 
 	// store id -> hot regions statistics as the role of leader
 	stats *storeStatistics
-	r     *rand.Rand
+	// r makes the random choices of the balance type, the kind of the
+	// operator and the order of the hot regions. It is seeded by the time
+	// unless the argument seed is passed, tests fix the seed by the argument
+	// or setRandSource to reproduce the decisions.
+	r *rand.Rand
+	// tags are attached to every operator created by the scheduler.
+	tags map[string]string
+	// reporter reports the decisions to the model service, pool sends the
+	// requests to the model service in the background.
+	reporter *modelReporter
+	pool     *modelWorkerPool
+	// predictions are the predictions of the active mode asked in the
+	// background, see predictDestStore.
+	predictions *hotPredictionCache
+	// breaker stops reporting to the model service while it keeps failing.
+	breaker *modelBreaker
+	client  *modelClient
+	// writeAmplification indicates whether to factor the write amplification
+	// of stores into the selection of hot write source stores.
+	writeAmplification bool
+	// leaderLabel restricts the destination of hot leaders, it takes no
+	// effect if the key is empty.
+	leaderLabel core.LeaderLabelConstraint
+	// exclusion is the stores neither moved from nor to.
+	exclusion storeExclusion `tunable:"exclusion"`
+	// featureSlots is the number of candidates described in the fixed-length
+	// feature vector sent to the model service, 0 means the features are
+	// only generated for the matching candidates.
+	featureSlots int
+	// criticalFlowBytes is the flow bytes above which a source store is
+	// critically hot, the relaxable constraints are relaxed for it if no
+	// destination is found. 0 means never relax.
+	criticalFlowBytes uint64
+	// minRegionFlowBytes is the flow bytes below which a hot region is not
+	// worth moving, such regions only count toward the store totals. It
+	// follows the cluster configuration "hot-region-min-flow-bytes" unless
+	// minRegionFlowBytesOverride is set by the argument or HTTP.
+	minRegionFlowBytes         uint64  `tunable:"min-region-flow-bytes"`
+	minRegionFlowBytesOverride *uint64 `tunable:"min-region-flow-bytes-override"`
+	// suspectStatsFactor is the calibrated ratio of the hot region flow to
+	// the store flow above which the statistics of a store are suspect,
+	// 0 means never check.
+	suspectStatsFactor float64
+	// applyLoadThreshold is the saturation of the apply pool above which a
+	// store is not the destination of the hot write peers, 0 means never
+	// check. It only works if the cluster reports the saturation.
+	applyLoadThreshold float64
+	// retryLimit is the number of the attempts to find a hot write region to
+	// balance in a dispatch.
+	retryLimit int `tunable:"retry-limit"`
+	// suspectStores are the stores with suspect statistics in the current
+	// dispatch and their calibrated ratios.
+	suspectStores map[uint64]float64
+	// excludedStores are the stores matched by the exclusion in the current
+	// dispatch.
+	excludedStores map[uint64]struct{}
+	// capacityWeight enables normalizing the hot load of the stores by their
+	// capacities, capacityWeights are the weights of the current dispatch,
+	// see storeCapacityWeights.
+	capacityWeight  bool
+	capacityWeights map[uint64]float64
+	// targetBlacklist are the stores never moved to, unlike the exclusion,
+	// their hot regions are still moved away to drain them, e.g. during
+	// the maintenance. It is set by the argument deny-target-store, and at
+	// runtime by AddTargetBlacklist.
+	targetBlacklist map[uint64]struct{} `tunable:"target-blacklist"`
+	// excludedTargets are the stores no hot peer or leader is moved to in
+	// the current dispatch, see excludedTargetStores.
+	excludedTargets map[uint64]struct{}
+	// keyRange is the key range of the hot regions scheduled, the regions
+	// not overlapping it are skipped.
+	keyRange hotKeyRange `tunable:"key-range"`
+	// expedite indicates more hot regions of the source store remain to be
+	// moved than the operator created by the current dispatch.
+	expedite bool
+	// relaxed indicates the current decision is made with relaxed constraints.
+	relaxed bool
+	// pauseWindows are the daily time windows in which no operator is created.
+	pauseWindows []pauseWindow
+	now          func() time.Time
+	// rankedCandidates are the candidates of the current decision ranked
+	// by the heuristics.
+	rankedCandidates []uint64
+	// features are the strategy features of the current decision.
+	features []Feature
+	// followerReadFraction is the estimated fraction of the reads the
+	// followers can serve, a hot read region is not moved if the rest of the
+	// reads are not hot. 0 means never estimate.
+	followerReadFraction float64
+	// dryRun makes Schedule log the operators instead of returning them, so
+	// the decisions can be previewed. The dispatch is a preview as DryRun.
+	dryRun bool `tunable:"dry-run"`
+	// configStorage persists the config changed at runtime, nil if there is
+	// none. persistMu keeps the saves in order.
+	configStorage schedule.ConfigStorage
+	persistMu     sync.Mutex
+	// region id -> the latest advisory of the hot read region.
+	advisories map[uint64]core.HotRegionAdvisory
+	// region id -> the flow moved by the running operator of the scheduler.
+	pendings map[uint64]*pendingInfluence
+	// clockSkew is the future-dated hot region stats per store.
+	clockSkew map[uint64]*storeClockSkew
+	// region id -> the time the region is last moved by the scheduler, the
+	// region is not moved again within regionCooldown, 0 means no cooldown.
+	movedRegions   map[uint64]time.Time
+	regionCooldown time.Duration
+	// disabledByVersion are the enabled features unsupported by the cluster
+	// version in the current dispatch, versionWarned are the features warned.
+	// clusterVersion is the cluster version of the last dispatch.
+	clusterVersion    semver.Version
+	disabledByVersion []string
+	versionWarned     map[string]struct{}
+	// history keeps the latest decisions, see GetHotRegionHistory.
+	history     *hotRegionHistory
+	reportCache *hotRegionReportCache
+	// decisionLog keeps the latest decisions whether or not they are
+	// emitted, see GetDecisions. lastDecision is the index of the last one
+	// logged in the current dispatch, -1 if there is none.
+	decisionLog  *hotDecisionLog
+	lastDecision int
+	// lastScore and lastStrategy are the model score and the strategy of
+	// the last decision made in the current dispatch, see
+	// ScheduleWithPriority.
+	lastScore    float64
+	lastStrategy string
+	// memoryBudget bounds the memory of the auxiliary structures, see
+	// enforceMemoryBudget.
+	memoryBudget uint64
+	// changes keeps the latest automatic changes, see setTunable.
+	changes *hotChangeLog
+	// hook receives the decisions for the model service, the one chosen by
+	// modelHook is used if it is nil.
+	hook modelHook
+	// trace records the evaluations of the decision if it is not nil.
+	trace *HotDecisionTrace
+	// preview is true in the dispatch of DryRun.
+	preview bool
+	// filterStats records the filter rejections of the current dispatch.
+	filterStats schedule.FilterStats
+	// summary describes the last dispatch, see GetSummary.
+	summary map[string]string
+	// tracer emits the spans of the decisions if it is not nil, span is the
+	// current span.
+	tracer opentracing.Tracer
+	span   opentracing.Span
+	// modelURL is the URL of the model service, it follows the cluster
+	// configuration "hot-region-model-url" unless modelEndpoint is set,
+	// in which case it is the endpoint. Empty means no model service.
+	modelURL      string  `tunable:"model-url"`
+	modelEndpoint *string `tunable:"model-endpoint"`
+	// modelMode is how the model service takes part in the decisions,
+	// modelThreshold is the minimal probability of a prediction to be
+	// followed in the active mode.
+	modelMode      modelMode `tunable:"model-mode"`
+	modelThreshold float64   `tunable:"model-threshold"`
+	// modelDisabled is the kill switch of the model service, it follows the
+	// cluster configuration "disable-hot-region-model" and modelKillSwitchEnv.
+	modelDisabled bool `tunable:"model-disabled"`
+	// modelSchema is the schema of the features passed at registration, 0
+	// means it follows featureSlots. negotiatedSchema is the one advertised
+	// by the model service at modelSchemaPath, 0 if there is none.
+	modelSchema      featureSchema
+	negotiatedSchema featureSchema
+	modelSchemaPath  string
+	// schemaDue makes the next dispatch negotiate the schema, it is set once
+	// the model URL changes or the circuit breaker recovers, which is
+	// tracked by breakerRecoveries. schemaNegotiation is the negotiation in
+	// flight, nil if there is none.
+	schemaDue         bool
+	breakerRecoveries uint64
+	schemaNegotiation *schemaNegotiation
+	// balanceMode constrains the operators to the leader transfers or the
+	// peer moves.
+	balanceMode balanceMode
 }
 
 func newBalanceHotRegionsScheduler(opController *schedule.OperatorController) *balanceHotRegionsScheduler {
 	base := newBaseScheduler(opController)
+	pool, client := newModelWorkerPool(hotRegionSchedulerName, defaultModelWorkers), newModelClient()
+	changes := newHotChangeLog(hotRegionSchedulerName, defaultHotChangeLogCapacity)
 	return &balanceHotRegionsScheduler{
-		baseScheduler: base,
-		limit:         1,
-		stats:         newStoreStaticstics(),
-		types:         []BalanceType{hotWriteRegionBalance, hotReadRegionBalance},
-		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
+		baseScheduler:      base,
+		readLimit:          1,
+		writeLimit:         1,
+		baseLimit:          1,
+		scheduleFactor:     hotRegionScheduleFactor,
+		limitFactor:        hotRegionLimitFactor,
+		minHotRegionCount:  defaultMinHotRegionCount,
+		modelMode:          modelModeShadow,
+		modelThreshold:     defaultModelThreshold,
+		stats:              newStoreStaticstics(),
+		types:              append([]BalanceType(nil), balanceTypes...),
+		r:                  rand.New(rand.NewSource(newRandSeed(cryptorand.Reader))),
+		tags:               make(map[string]string),
+		reporter:           newModelReporter(pool, client),
+		pool:               pool,
+		predictions:        newHotPredictionCache(defaultModelPredictionTTL),
+		client:             client,
+		breaker:            newModelBreaker(changes),
+		history:            newHotRegionHistory(defaultHotRegionHistoryCapacity),
+		decisionLog:        newHotDecisionLog(defaultDecisionLogCapacity),
+		capacityWeight:     true,
+		memoryBudget:       defaultMemoryBudget,
+		lastDecision:       -1,
+		advisories:         make(map[uint64]core.HotRegionAdvisory),
+		pendings:           make(map[uint64]*pendingInfluence),
+		clockSkew:          make(map[uint64]*storeClockSkew),
+		movedRegions:       make(map[uint64]time.Time),
+		versionWarned:      make(map[string]struct{}),
+		changes:            changes,
+		now:                time.Now,
+		suspectStatsFactor: defaultSuspectStatsFactor,
+		applyLoadThreshold: defaultApplyLoadThreshold,
+		retryLimit:         defaultHotRetryLimit,
+		modelSchemaPath:    defaultModelSchemaPath,
 	}
 }
 
 func newBalanceHotReadRegionsScheduler(opController *schedule.OperatorController) *balanceHotRegionsScheduler {
-	base := newBaseScheduler(opController)
-	return &balanceHotRegionsScheduler{
-		baseScheduler: base,
-		limit:         1,
-		stats:         newStoreStaticstics(),
-		types:         []BalanceType{hotReadRegionBalance},
-		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
-	}
+	h := newBalanceHotRegionsScheduler(opController)
+	h.types = []BalanceType{hotReadRegionBalance}
+	return h
 }
 
 func newBalanceHotWriteRegionsScheduler(opController *schedule.OperatorController) *balanceHotRegionsScheduler {
-	base := newBaseScheduler(opController)
-	return &balanceHotRegionsScheduler{
-		baseScheduler: base,
-		limit:         1,
-		stats:         newStoreStaticstics(),
-		types:         []BalanceType{hotWriteRegionBalance},
-		r:             rand.New(rand.NewSource(time.Now().UnixNano())),
+	h := newBalanceHotRegionsScheduler(opController)
+	h.types = []BalanceType{hotWriteRegionBalance}
+	return h
+}
+
+// applyArgs applies the arguments passed at registration. The optional first
+// argument without "=" is the limit of the running hot region operators, the
+// others are in "name=value" style.
+// "tag=key:value" attaches the tag to every operator created by the scheduler,
+// "model-dedup" and "model-dedup-window" control how identical updates
+// reported to the model service are merged, "model-batch-size" and
+// "model-batch-delay" bound how many updates are sent together and how long
+// they wait, "write-amplification" enables
+// draining the stores with high write amplification first, "leader-label=key:value"
+// and "leader-label-strict" restrict the stores that hot leaders can move to,
+// "model-feature-slots" enables the fixed-length feature vector,
+// "model-schema" is the schema of the features, "v1" or "v2", used unless the
+// model service advertises one at "model-schema-path",
+// "critical-flow-bytes" enables the relaxed retry for critically hot sources,
+// "pause-window=15:04-15:04" adds a daily window in which scheduling is paused,
+// "min-region-flow-bytes" is the minimal flow bytes of a region to be moved
+// instead of the cluster configuration "hot-region-min-flow-bytes",
+// "tracing=true" emits the spans of the decisions to the global tracer,
+// "suspect-stats-factor" is the factor to flag the stores with suspect stats,
+// "apply-load-threshold" is the saturation of the apply pool above which a
+// store doesn't receive the hot write peers, 0 to disable the check,
+// "retry-limit" is the number of the attempts to find a hot write region to
+// balance in a dispatch, in [1, 100],
+// "model-workers" is the number of workers sending the model requests,
+// "model-breaker-failures" and "model-breaker-cooldown" control when the
+// model service is skipped after failures, "model-endpoint" is the URL of the
+// model service overriding the cluster configuration, empty to disable it,
+// "model-timeout" is the timeout of the model requests, "max-limit" clamps
+// the limit adjusted by the surplus of the source store, "schedule-factor"
+// and "limit-factor" override hotRegionScheduleFactor and hotRegionLimitFactor,
+// "min-hot-region-count" is the minimal number of hot regions of a source store,
+// "model-mode" is one of "off", "shadow" and "active", "balance-mode" is one
+// of "both", "leader-only" and "peer-only", "model-threshold" is
+// the minimal probability of a prediction followed in the active mode,
+// "follower-read-fraction" is the estimated fraction of the reads served by
+// the followers, in [0, 1), "exclude-store=4,7" and
+// "exclude-labels=tier:archive" exclude the stores by IDs and labels,
+// "keys-weight" is the weight of the flow keys in the score of the stores,
+// "region-cooldown=30s" keeps a moved region from moving again within the
+// duration, "deny-target-store=4,7" keeps the hot regions from moving to the stores while
+// still moving them away, "start-key" and "end-key" are the hex-encoded
+// keys of the range of the hot regions scheduled, empty for the whole
+// keyspace, "seed" fixes the seed of the random choices, "dry-run=true"
+// logs the operators instead of executing them, "decision-log-size" is the
+// number of the latest decisions kept in the decision log,
+// "capacity-weight=false" compares the hot load of the stores regardless of
+// their capacities, "memory-budget=64MiB" bounds the memory of the
+// auxiliary structures of the scheduler.
+func (h *balanceHotRegionsScheduler) applyArgs(args []string) error {
+	if len(args) > 0 && !strings.Contains(args[0], "=") {
+		h.baseLimit = parseHotRegionLimit(args[0])
+		h.readLimit, h.writeLimit = h.baseLimit, h.baseLimit
+		args = args[1:]
+	}
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return errors.Errorf("invalid hot region scheduler argument %q", arg)
+		}
+		switch kv[0] {
+		case "tag":
+			tag := strings.SplitN(kv[1], ":", 2)
+			if len(tag) != 2 || tag[0] == "" {
+				return errors.Errorf("invalid operator tag %q", kv[1])
+			}
+			h.tags[tag[0]] = tag[1]
+		case "model-dedup":
+			dedup, err := strconv.ParseBool(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.reporter.dedup = dedup
+		case "model-dedup-window":
+			window, err := time.ParseDuration(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.reporter.window = window
+		case "model-batch-size":
+			size, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if size <= 0 {
+				return errors.Errorf("invalid model batch size %d", size)
+			}
+			h.reporter.batchSize = size
+		case "decision-log-size":
+			size, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if size <= 0 {
+				return errors.Errorf("invalid decision log size %d", size)
+			}
+			h.decisionLog = newHotDecisionLog(size)
+		case "model-batch-delay":
+			delay, err := time.ParseDuration(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.reporter.batchDelay = delay
+		case "write-amplification":
+			enable, err := strconv.ParseBool(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.writeAmplification = enable
+		case "memory-budget":
+			budget, err := gh.ParseBytes(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if budget == 0 {
+				return errors.New("invalid memory budget 0")
+			}
+			h.memoryBudget = budget
+		case "capacity-weight":
+			enable, err := strconv.ParseBool(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.capacityWeight = enable
+		case "leader-label":
+			label := strings.SplitN(kv[1], ":", 2)
+			if len(label) != 2 || label[0] == "" || label[1] == "" {
+				return errors.Errorf("invalid leader label %q", kv[1])
+			}
+			h.leaderLabel.Key, h.leaderLabel.Value = label[0], label[1]
+		case "leader-label-strict":
+			strict, err := strconv.ParseBool(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.leaderLabel.Strict = strict
+		case "model-schema":
+			schema, err := parseFeatureSchema(kv[1])
+			if err != nil {
+				return err
+			}
+			h.modelSchema = schema
+		case "model-schema-path":
+			if !strings.HasPrefix(kv[1], "/") {
+				return errors.Errorf("invalid model schema path %q", kv[1])
+			}
+			h.modelSchemaPath = kv[1]
+		case "model-feature-slots":
+			slots, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if slots < 0 {
+				return errors.Errorf("invalid model feature slots %d", slots)
+			}
+			h.featureSlots = slots
+		case "critical-flow-bytes":
+			flowBytes, err := strconv.ParseUint(kv[1], 10, 64)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.criticalFlowBytes = flowBytes
+		case "pause-window":
+			window, err := parsePauseWindow(kv[1])
+			if err != nil {
+				return err
+			}
+			h.pauseWindows = append(h.pauseWindows, window)
+		case "min-region-flow-bytes":
+			flowBytes, err := strconv.ParseUint(kv[1], 10, 64)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.minRegionFlowBytes, h.minRegionFlowBytesOverride = flowBytes, &flowBytes
+		case "suspect-stats-factor":
+			factor, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if factor < 0 {
+				return errors.Errorf("invalid suspect stats factor %v", factor)
+			}
+			h.suspectStatsFactor = factor
+		case "apply-load-threshold":
+			threshold, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if !(threshold >= 0 && threshold <= 1) {
+				return errors.Errorf("invalid apply load threshold %v, it must be in [0, 1]", threshold)
+			}
+			h.applyLoadThreshold = threshold
+		case "retry-limit":
+			limit, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if err := validateRetryLimit(limit); err != nil {
+				return err
+			}
+			h.retryLimit = limit
+		case "model-workers":
+			workers, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if workers <= 0 {
+				return errors.Errorf("invalid model workers %d", workers)
+			}
+			h.pool.setWorkers(workers)
+		case "model-breaker-failures":
+			failures, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if failures < 0 {
+				return errors.Errorf("invalid model breaker failures %d", failures)
+			}
+			h.breaker.failures = failures
+		case "model-breaker-cooldown":
+			cooldown, err := time.ParseDuration(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.breaker.cooldown = cooldown
+		case "region-cooldown":
+			cooldown, err := time.ParseDuration(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if cooldown < 0 {
+				return errors.Errorf("invalid region cooldown %v", cooldown)
+			}
+			h.regionCooldown = cooldown
+		case "model-endpoint":
+			endpoint, err := parseModelEndpoint(kv[1])
+			if err != nil {
+				return err
+			}
+			h.modelEndpoint = &endpoint
+		case "model-timeout":
+			timeout, err := time.ParseDuration(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if timeout <= 0 {
+				return errors.Errorf("invalid model timeout %v", timeout)
+			}
+			h.client.timeout = timeout
+		case "max-limit":
+			maxLimit, err := strconv.ParseUint(kv[1], 10, 64)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.maxLimit = maxLimit
+		case "schedule-factor", "limit-factor":
+			factor, err := parseHotRegionFactor(kv[0], kv[1])
+			if err != nil {
+				return err
+			}
+			if kv[0] == "schedule-factor" {
+				h.scheduleFactor = factor
+			} else {
+				h.limitFactor = factor
+			}
+		case "min-hot-region-count":
+			count, err := strconv.Atoi(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if count < 1 {
+				return errors.Errorf("invalid min hot region count %d", count)
+			}
+			h.minHotRegionCount = count
+		case "model-mode":
+			mode, err := parseModelMode(kv[1])
+			if err != nil {
+				return err
+			}
+			h.modelMode = mode
+		case "balance-mode":
+			mode, err := parseBalanceMode(kv[1])
+			if err != nil {
+				return err
+			}
+			h.balanceMode = mode
+		case "model-threshold":
+			threshold, err := parseHotRegionFactor("model threshold", kv[1])
+			if err != nil {
+				return err
+			}
+			h.modelThreshold = threshold
+		case "follower-read-fraction":
+			fraction, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if !(fraction >= 0 && fraction < 1) {
+				return errors.Errorf("invalid follower read fraction %v, it must be in [0, 1)", fraction)
+			}
+			h.followerReadFraction = fraction
+		case "keys-weight":
+			weight, err := strconv.ParseFloat(kv[1], 64)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if err := validateKeysWeight(weight); err != nil {
+				return err
+			}
+			h.keysWeight = weight
+		case "exclude-store", "exclude-labels":
+			cfg := h.exclusion.config()
+			var err error
+			if kv[0] == "exclude-store" {
+				cfg.StoreIDs, err = parseExcludeStores(kv[1])
+			} else {
+				cfg.Labels, err = parseExcludeLabels(kv[1])
+			}
+			if err != nil {
+				return err
+			}
+			if h.exclusion, err = newStoreExclusion(cfg); err != nil {
+				return err
+			}
+		case "deny-target-store":
+			storeIDs, err := parseExcludeStores(kv[1])
+			if err != nil {
+				return err
+			}
+			h.targetBlacklist = make(map[uint64]struct{}, len(storeIDs))
+			for _, id := range storeIDs {
+				h.targetBlacklist[id] = struct{}{}
+			}
+		case "start-key", "end-key":
+			key, err := parseHexKey(kv[0], kv[1])
+			if err != nil {
+				return err
+			}
+			if kv[0] == "start-key" {
+				h.keyRange.startKey = key
+			} else {
+				h.keyRange.endKey = key
+			}
+		case "dry-run":
+			dryRun, err := strconv.ParseBool(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.dryRun = dryRun
+		case "seed":
+			seed, err := strconv.ParseInt(kv[1], 10, 64)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.r = rand.New(rand.NewSource(seed))
+		case "tracing":
+			enable, err := strconv.ParseBool(kv[1])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			h.tracer = nil
+			if enable {
+				h.tracer = opentracing.GlobalTracer()
+			}
+		default:
+			return errors.Errorf("unknown hot region scheduler argument %q", kv[0])
+		}
+	}
+	return h.keyRange.validate()
+}
+
+// newRandSeed returns a seed read from the reader, so that the schedulers
+// started at the same time don't make the same random choices. If it fails,
+// the seed falls back to the current time XOR the process ID.
+func newRandSeed(reader io.Reader) int64 {
+	var b [8]byte
+	if _, err := io.ReadFull(reader, b[:]); err != nil {
+		log.Warnf("[%s] failed to read a random seed, fall back to the time: %v", hotRegionSchedulerName, err)
+		return time.Now().UnixNano() ^ int64(os.Getpid())
 	}
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+// setRandSource replaces the source of the random choices.
+func (h *balanceHotRegionsScheduler) setRandSource(src rand.Source) {
+	h.Lock()
+	defer h.Unlock()
+	h.r = rand.New(src)
 }
 
 func (h *balanceHotRegionsScheduler) GetName() string {
-	return "balance-hot-region-scheduler"
+	return hotRegionSchedulerName
 }
 
 func (h *balanceHotRegionsScheduler) GetType() string {
 	return "hot-region"
 }
 
+func (h *balanceHotRegionsScheduler) Cleanup(cluster schedule.Cluster) {
+	h.Lock()
+	defer h.Unlock()
+	h.reporter.close(time.Now())
+	// The requests in flight are canceled, so the workers exit promptly.
+	h.pool.stop()
+	h.client.cancel()
+	h.pool.wait()
+}
+
 func (h *balanceHotRegionsScheduler) IsScheduleAllowed(cluster schedule.Cluster) bool {
-	return h.allowBalanceLeader(cluster) || h.allowBalanceRegion(cluster)
+	h.RLock()
+	defer h.RUnlock()
+	for _, typ := range h.types {
+		if h.allowBalanceLeader(cluster, typ) || h.allowBalanceRegion(cluster, typ) {
+			return true
+		}
+	}
+	return false
 }
 
-func (h *balanceHotRegionsScheduler) allowBalanceLeader(cluster schedule.Cluster) bool {
-	return h.opController.OperatorCount(schedule.OpHotRegion) < h.limit &&
+func (h *balanceHotRegionsScheduler) allowBalanceLeader(cluster schedule.Cluster, typ BalanceType) bool {
+	// The operators of the dry run are never added, so they are not limited.
+	if h.preview {
+		return true
+	}
+	return h.opController.OperatorCount(schedule.OpHotRegion) < *h.limitOf(typ) &&
 		h.opController.OperatorCount(schedule.OpLeader) < cluster.GetLeaderScheduleLimit()
 }
 
-func (h *balanceHotRegionsScheduler) allowBalanceRegion(cluster schedule.Cluster) bool {
-	return h.opController.OperatorCount(schedule.OpHotRegion) < h.limit &&
+func (h *balanceHotRegionsScheduler) allowBalanceRegion(cluster schedule.Cluster, typ BalanceType) bool {
+	if h.preview {
+		return true
+	}
+	return h.opController.OperatorCount(schedule.OpHotRegion) < *h.limitOf(typ) &&
 		h.opController.OperatorCount(schedule.OpRegion) < cluster.GetRegionScheduleLimit()
 }
 
+// limitOf returns the limit of the balance type. The hot keys balancing
+// only transfers the leaders to spread the reads, so it shares the limit
+// with the read balancing.
+func (h *balanceHotRegionsScheduler) limitOf(typ BalanceType) *uint64 {
+	if typ == hotWriteRegionBalance {
+		return &h.writeLimit
+	}
+	return &h.readLimit
+}
+
+// limitName returns the name of the limit of the balance type in the
+// change log.
+func limitName(typ BalanceType) string {
+	if typ == hotWriteRegionBalance {
+		return "write-limit"
+	}
+	return "read-limit"
+}
+
 func (h *balanceHotRegionsScheduler) Schedule(cluster schedule.Cluster) []*schedule.Operator {
+	return scoredOperators(h.scheduleScored(cluster))
+}
+
+// scheduleScored is Schedule with the operators annotated by their scores.
+func (h *balanceHotRegionsScheduler) scheduleScored(cluster schedule.Cluster) []ScoredOperator {
 	schedulerCounter.WithLabelValues(h.GetName(), "schedule").Inc()
-	return h.dispatch(h.types[h.r.Int()%len(h.types)], cluster)
+	// The random source is changed by the choice, so the write lock is
+	// needed.
+	h.Lock()
+	typ, dryRun := h.types[h.r.Int()%len(h.types)], h.dryRun
+	h.Unlock()
+	start := time.Now()
+	scored := h.dispatchScored(typ, cluster, dryRun)
+	hotScheduleLatency.WithLabelValues(typ.String()).Observe(time.Since(start).Seconds())
+	if dryRun && len(scored) != 0 {
+		for _, s := range scored {
+			op := s.Op
+			log.Infof("[DRY-RUN] [%s] proposed operator %s%s on region %d, balance type %s", h.GetName(), op.Desc(), op.Detail(), op.RegionID(), typ)
+			schedulerCounter.WithLabelValues(h.GetName(), "dry_run").Inc()
+		}
+		h.Lock()
+		h.summary[hotSummaryDryRun] = "true"
+		h.Unlock()
+		return nil
+	}
+	return scored
+}
+
+// DryRun runs a dispatch as Schedule does and returns the operators to the
+// caller, which are never added to the operator controller. Unlike
+// SetDryRun, the dispatch is not limited by the running operators, so it
+// doesn't need IsScheduleAllowed. Both neither talk to the model service
+// nor change the state of the scheduler, e.g. the limits, the pending
+// influence, the cooldown, the history, the change log and the prediction
+// cache. The dry run also keeps the statistics and the advisories, which it
+// computes on copies. So the operators are the ones Schedule would create
+// with the same random choices, e.g. by a scheduler with the same seed. The
+// dry run draws its random choices as Schedule does, so the next Schedule
+// makes different ones.
+func (h *balanceHotRegionsScheduler) DryRun(cluster schedule.Cluster) []*schedule.Operator {
+	h.Lock()
+	defer h.Unlock()
+	typ := h.types[h.r.Int()%len(h.types)]
+	h.preview = true
+	stats, pendings, advisories := h.stats, h.pendings, h.advisories
+	statsCopy := *stats
+	h.stats = &statsCopy
+	h.pendings = make(map[uint64]*pendingInfluence, len(pendings))
+	for regionID, p := range pendings {
+		h.pendings[regionID] = p
+	}
+	h.advisories = make(map[uint64]core.HotRegionAdvisory, len(advisories))
+	for regionID, a := range advisories {
+		h.advisories[regionID] = a
+	}
+	defer func() {
+		h.preview = false
+		h.stats, h.pendings, h.advisories = stats, pendings, advisories
+	}()
+	ops := h.dispatchLocked(typ, cluster)
+	h.summary[hotSummaryDryRun] = "true"
+	return ops
+}
+
+// hypothetical reports whether the current decision is made for tracing or
+// the dry run, which must have no side effect.
+func (h *balanceHotRegionsScheduler) hypothetical() bool {
+	return h.trace != nil || h.preview
+}
+
+// SetDryRun enables or disables the dry run from the next schedule. In the
+// dry run, the operators are logged instead of returned, and the dispatch
+// changes no state of the scheduler as DryRun.
+func (h *balanceHotRegionsScheduler) SetDryRun(dryRun bool) {
+	h.Lock()
+	err := h.setTunable("dry-run", dryRun, core.HotChangeSourceUser, hotConfigUpdateReason)
+	h.Unlock()
+	if err != nil {
+		log.Errorf("[%s] failed to change the dry run: %v", h.GetName(), err)
+		return
+	}
+	h.persistConfig()
+}
+
+// GetFactors returns the schedule factor and the limit factor.
+func (h *balanceHotRegionsScheduler) GetFactors() core.HotRegionFactors {
+	h.RLock()
+	defer h.RUnlock()
+	return core.HotRegionFactors{ScheduleFactor: h.scheduleFactor, LimitFactor: h.limitFactor}
+}
+
+// SetFactors changes the schedule factor and the limit factor from the next
+// schedule, the zero factor is not changed.
+func (h *balanceHotRegionsScheduler) SetFactors(factors core.HotRegionFactors) error {
+	if err := validateHotRegionFactors(factors); err != nil {
+		return err
+	}
+	h.Lock()
+	var err error
+	if factors.ScheduleFactor != 0 {
+		err = h.setTunable("schedule-factor", factors.ScheduleFactor, core.HotChangeSourceUser, hotConfigUpdateReason)
+	}
+	if err == nil && factors.LimitFactor != 0 {
+		err = h.setTunable("limit-factor", factors.LimitFactor, core.HotChangeSourceUser, hotConfigUpdateReason)
+	}
+	h.Unlock()
+	if err != nil {
+		return err
+	}
+	h.persistConfig()
+	return nil
+}
+
+// SetTypes changes the balance types the scheduler dispatches from the next
+// schedule, e.g. to disable the hot write balancing temporarily. The types
+// must not be empty and must be known.
+func (h *balanceHotRegionsScheduler) SetTypes(types []BalanceType) error {
+	if len(types) == 0 {
+		return errors.New("no balance type")
+	}
+	for _, t := range types {
+		if !isKnownBalanceType(t) {
+			return errors.Errorf("unknown balance type %d", t)
+		}
+	}
+	types = append([]BalanceType(nil), types...)
+	h.Lock()
+	defer h.Unlock()
+	return h.setTunable("types", types, core.HotChangeSourceUser, hotConfigUpdateReason)
+}
+
+// SetBalanceTypes is SetTypes with the names of the balance types, e.g.
+// "hot-read".
+func (h *balanceHotRegionsScheduler) SetBalanceTypes(names []string) error {
+	types := make([]BalanceType, 0, len(names))
+	for _, name := range names {
+		t, err := parseBalanceType(name)
+		if err != nil {
+			return err
+		}
+		types = append(types, t)
+	}
+	return h.SetTypes(types)
 }
 
 func (h *balanceHotRegionsScheduler) dispatch(typ BalanceType, cluster schedule.Cluster) []*schedule.Operator {
 	h.Lock()
 	defer h.Unlock()
+	return h.dispatchLocked(typ, cluster)
+}
+
+// dispatchLocked is dispatch with the lock held.
+func (h *balanceHotRegionsScheduler) dispatchLocked(typ BalanceType, cluster schedule.Cluster) []*schedule.Operator {
+	h.rankedCandidates = nil
+	h.features = nil
+	h.lastDecision = -1
+	h.lastScore, h.lastStrategy = 0, ""
+	h.limitInputs = ""
+	h.balanceType = typ
+	h.dimension = bytesDimension
+	h.pinDimension = false
+	h.bytesPerKey = 0
+	h.relaxed = false
+	h.filterStats = make(schedule.FilterStats)
+	h.suspectStores = nil
+	h.excludedStores = h.exclusion.excludedStores(cluster.GetStores())
+	h.excludedTargets = h.excludedTargetStores()
+	h.capacityWeights = nil
+	if h.capacityWeight {
+		h.capacityWeights = storeCapacityWeights(cluster.GetStores())
+	}
+	h.prunePendingInfluence()
+	h.expedite = false
+	h.summary = map[string]string{hotTagBalanceType: typ.String()}
+	span, finish := h.startSpan(hotSpanDispatch)
+	defer func() {
+		setSpanTag(span, hotSpanTagOutcome, h.summary[hotTagReason])
+		finish()
+	}()
+	setSpanTag(span, hotSpanTagBalanceType, typ.String())
+	now := h.now()
+	// The preview follows the configuration without changing it, the expired
+	// cooldown and predictions are already ignored by their lookups.
+	if !h.preview {
+		h.pruneMovedRegions(now)
+		h.predictions.prune(now)
+		h.updateModelURL(cluster)
+		h.updateModelSchema()
+		h.updateMinRegionFlowBytes(cluster)
+	}
+	h.updateFeatureGates(cluster)
+	if !h.preview {
+		h.reporter.flush(now, false)
+		h.enforceMemoryBudget(now)
+	}
+	// The statistics are still updated when paused.
+	paused := h.isPaused(now)
+	if paused {
+		schedulerCounter.WithLabelValues(h.GetName(), "paused").Inc()
+		h.summary[hotTagReason] = "paused"
+	}
+	h.refreshStats(cluster, now)
 	switch typ {
 	case hotReadRegionBalance:
-		h.stats.readStatAsLeader = h.calcScore(cluster.RegionReadStats(), cluster, core.LeaderKind)
+		h.pruneAdvisories(h.stats.readStatAsLeader)
+		h.suspectStores = h.checkSuspectStats(cluster, h.stats.readStatAsLeader, func(store *core.StoreInfo) float64 {
+			return store.RollingStoreStats.GetBytesReadRate()
+		})
+		if paused {
+			return nil
+		}
 		return h.balanceHotReadRegions(cluster)
 	case hotWriteRegionBalance:
-		h.stats.writeStatAsLeader = h.calcScore(cluster.RegionWriteStats(), cluster, core.LeaderKind)
-		h.stats.writeStatAsPeer = h.calcScore(cluster.RegionWriteStats(), cluster, core.RegionKind)
+		h.suspectStores = h.checkSuspectStats(cluster, h.stats.writeStatAsPeer, func(store *core.StoreInfo) float64 {
+			return store.RollingStoreStats.GetBytesWriteRate()
+		})
+		h.stats.writeAmplification = h.calcWriteAmplification(cluster)
+		if paused {
+			return nil
+		}
 		return h.balanceHotWriteRegions(cluster)
+	case hotKeysRegionBalance:
+		if h.disabledByVersionGate(hotFeatureKeysFlow) {
+			h.summary[hotTagReason] = "disabled-by-version"
+			return nil
+		}
+		h.stats.readStatAsLeaderByKeys = h.calcScoreByKeys(cluster.RegionReadStats(), cluster, core.LeaderKind)
+		h.stats.writeStatAsLeaderByKeys = h.calcScoreByKeys(cluster.RegionWriteStats(), cluster, core.LeaderKind)
+		if paused {
+			return nil
+		}
+		// The hot keys balancing only transfers the leaders.
+		if !h.balanceMode.allowLeader() {
+			h.summary[hotTagReason] = "disabled-by-balance-mode"
+			return nil
+		}
+		h.dimension, h.pinDimension = keysDimension, true
+		return h.balanceHotKeysRegions(cluster)
+	}
+	return nil
+}
+
+// refreshStats calculates the read and write statistics whatever the balance
+// type of the dispatch is, so the status is never older than the last
+// dispatch.
+func (h *balanceHotRegionsScheduler) refreshStats(cluster schedule.Cluster, now time.Time) {
+	h.stats.readStatAsLeader = h.calcScore(cluster.RegionReadStats(), cluster, core.LeaderKind)
+	h.stats.writeStatAsLeader = h.calcScore(cluster.RegionWriteStats(), cluster, core.LeaderKind)
+	h.stats.writeStatAsPeer = h.calcScore(cluster.RegionWriteStats(), cluster, core.RegionKind)
+	h.stats.lastUpdate = now
+}
+
+// parseHotRegionFactor parses a factor of the hot region scheduler, which
+// must be in (0, 1].
+func parseHotRegionFactor(name, s string) (float64, error) {
+	factor, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if !(factor > 0 && factor <= 1) {
+		return 0, errors.Errorf("invalid %s %v, it must be in (0, 1]", name, factor)
+	}
+	return factor, nil
+}
+
+// validateHotRegionFactors checks the factors are in (0, 1], the zero factor
+// means unchanged.
+func validateHotRegionFactors(factors core.HotRegionFactors) error {
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{
+		{"schedule-factor", factors.ScheduleFactor},
+		{"limit-factor", factors.LimitFactor},
+	} {
+		if f.value != 0 && !(f.value > 0 && f.value <= 1) {
+			return errors.Errorf("invalid %s %v, it must be in (0, 1]", f.name, f.value)
+		}
 	}
 	return nil
 }
 
+// parseHotRegionLimit parses the limit of the running hot region operators,
+// it falls back to 1 if the limit is invalid.
+func parseHotRegionLimit(s string) uint64 {
+	limit, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || limit == 0 {
+		log.Warnf("invalid hot region scheduler limit %q, use 1 instead", s)
+		return 1
+	}
+	return limit
+}
+
+// pauseWindow is a daily time window, start and end are the offsets from
+// the midnight. The window crosses the midnight if start is after end.
+type pauseWindow struct {
+	start, end time.Duration
+}
+
+// parsePauseWindow parses the window in "15:04-15:04" format.
+func parsePauseWindow(s string) (pauseWindow, error) {
+	bounds := strings.SplitN(s, "-", 2)
+	if len(bounds) != 2 {
+		return pauseWindow{}, errors.Errorf("invalid pause window %q", s)
+	}
+	var offsets [2]time.Duration
+	for i, bound := range bounds {
+		t, err := time.Parse("15:04", bound)
+		if err != nil {
+			return pauseWindow{}, errors.WithStack(err)
+		}
+		offsets[i] = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	return pauseWindow{start: offsets[0], end: offsets[1]}, nil
+}
+
+func (w pauseWindow) contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.start <= w.end {
+		return offset >= w.start && offset < w.end
+	}
+	return offset >= w.start || offset < w.end
+}
+
+func (h *balanceHotRegionsScheduler) isPaused(now time.Time) bool {
+	for _, w := range h.pauseWindows {
+		if w.contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *balanceHotRegionsScheduler) balanceHotReadRegions(cluster schedule.Cluster) []*schedule.Operator {
 	// balance by leader
-	srcRegion, newLeader := h.balanceByLeader(cluster, h.stats.readStatAsLeader)
+	var (
+		srcRegion                    *core.RegionInfo
+		newLeader, srcPeer, destPeer *metapb.Peer
+	)
+	if h.balanceMode.allowLeader() {
+		srcRegion, newLeader = h.balanceByLeader(cluster, h.stats.readStatAsLeader, nil)
+	}
 	if srcRegion != nil {
 		schedulerCounter.WithLabelValues(h.GetName(), "move_leader").Inc()
 		step := schedule.TransferLeader{FromStore: srcRegion.GetLeader().GetStoreId(), ToStore: newLeader.GetStoreId()}
-		return []*schedule.Operator{schedule.NewOperator("transferHotReadLeader", srcRegion.GetID(), srcRegion.GetRegionEpoch(), schedule.OpHotRegion|schedule.OpLeader, step)}
+		op := schedule.NewOperator("transferHotReadLeader", srcRegion.GetID(), srcRegion.GetRegionEpoch(), schedule.OpHotRegion|schedule.OpLeader, step)
+		op.SetDetail(hotOperatorDetail(srcRegion.GetID(), h.stats.readStatAsLeader, step.FromStore, step.ToStore))
+		h.tagOperator(op, hotReadRegionBalance, "transfer-leader")
+		h.reportDecision(srcRegion.GetID(), step, step.FromStore, step.ToStore)
+		h.recordDecision(hotReadRegionBalance, srcRegion, h.stats.readStatAsLeader, step.FromStore, step.ToStore)
+		h.addPendingInfluence(op, hotReadRegionBalance, h.stats.readStatAsLeader, step.FromStore, step.ToStore)
+		return []*schedule.Operator{op}
 	}
 
 	// balance by peer
-	srcRegion, srcPeer, destPeer := h.balanceByPeer(cluster, h.stats.readStatAsLeader)
+	if h.balanceMode.allowPeer() {
+		srcRegion, srcPeer, destPeer = h.balanceByPeer(cluster, h.stats.readStatAsLeader, nil)
+	}
 	if srcRegion != nil {
 		schedulerCounter.WithLabelValues(h.GetName(), "move_peer").Inc()
-		return []*schedule.Operator{schedule.CreateMovePeerOperator("moveHotReadRegion", cluster, srcRegion, schedule.OpHotRegion, srcPeer.GetStoreId(), destPeer.GetStoreId(), destPeer.GetId())}
+		op := schedule.CreateMovePeerOperator("moveHotReadRegion", cluster, srcRegion, schedule.OpHotRegion, srcPeer.GetStoreId(), destPeer.GetStoreId(), destPeer.GetId())
+		op.SetDetail(hotOperatorDetail(srcRegion.GetID(), h.stats.readStatAsLeader, srcPeer.GetStoreId(), destPeer.GetStoreId()))
+		h.tagOperator(op, hotReadRegionBalance, "move-peer")
+		h.recordDecision(hotReadRegionBalance, srcRegion, h.stats.readStatAsLeader, srcPeer.GetStoreId(), destPeer.GetStoreId())
+		h.addPendingInfluence(op, hotReadRegionBalance, h.stats.readStatAsLeader, srcPeer.GetStoreId(), destPeer.GetStoreId())
+		return []*schedule.Operator{op}
+	}
+	schedulerCounter.WithLabelValues(h.GetName(), "skip").Inc()
+	h.summarizeSkip()
+	return nil
+}
+
+// balanceHotKeysRegions transfers a hot leader by the read keys first, then by
+// the write keys. Only the leaders are moved, since they serve the requests.
+func (h *balanceHotRegionsScheduler) balanceHotKeysRegions(cluster schedule.Cluster) []*schedule.Operator {
+	for _, storesStat := range []core.StoreHotRegionsStat{h.stats.readStatAsLeaderByKeys, h.stats.writeStatAsLeaderByKeys} {
+		srcRegion, newLeader := h.balanceByLeader(cluster, storesStat, nil)
+		if srcRegion != nil {
+			schedulerCounter.WithLabelValues(h.GetName(), "move_leader").Inc()
+			step := schedule.TransferLeader{FromStore: srcRegion.GetLeader().GetStoreId(), ToStore: newLeader.GetStoreId()}
+			op := schedule.NewOperator("transferHotKeysLeader", srcRegion.GetID(), srcRegion.GetRegionEpoch(), schedule.OpHotRegion|schedule.OpLeader, step)
+			op.SetDetail(hotOperatorDetail(srcRegion.GetID(), storesStat, step.FromStore, step.ToStore))
+			h.tagOperator(op, hotKeysRegionBalance, "transfer-leader")
+			h.reportDecision(srcRegion.GetID(), step, step.FromStore, step.ToStore)
+			h.recordDecision(hotKeysRegionBalance, srcRegion, storesStat, step.FromStore, step.ToStore)
+			h.addPendingInfluence(op, hotKeysRegionBalance, storesStat, step.FromStore, step.ToStore)
+			return []*schedule.Operator{op}
+		}
 	}
 	schedulerCounter.WithLabelValues(h.GetName(), "skip").Inc()
+	h.summarizeSkip()
 	return nil
 }
 
-// balanceHotRetryLimit is the limit to retry schedule for selected balance strategy.
-const balanceHotRetryLimit = 10
+// defaultSuspectStatsFactor is the default factor to flag the suspect stats.
+const defaultSuspectStatsFactor = 2
+
+// defaultApplyLoadThreshold is the default saturation of the apply pool above
+// which a store doesn't receive the hot write peers.
+const defaultApplyLoadThreshold = 0.9
+
+// defaultMinHotRegionCount is the default minimal number of hot regions of a
+// source store.
+const defaultMinHotRegionCount = 2
+
+// defaultHotRetryLimit is the default limit to retry schedule for selected
+// balance strategy.
+const defaultHotRetryLimit = 10
+
+// maxHotRetryLimit is the maximal limit to retry schedule, which bounds the
+// time of a dispatch.
+const maxHotRetryLimit = 100
 
 func (h *balanceHotRegionsScheduler) balanceHotWriteRegions(cluster schedule.Cluster) []*schedule.Operator {
-	for i := 0; i < balanceHotRetryLimit; i++ {
-		switch h.r.Int() % 2 {
+	for i := 0; i < h.retryLimit; i++ {
+		strategy := h.r.Int() % 2
+		switch h.balanceMode {
+		case balanceModePeerOnly:
+			strategy = 0
+		case balanceModeLeaderOnly:
+			strategy = 1
+		}
+		switch strategy {
 		case 0:
 			// balance by peer
-			srcRegion, srcPeer, destPeer := h.balanceByPeer(cluster, h.stats.writeStatAsPeer)
+			srcRegion, srcPeer, destPeer := h.balanceByPeer(cluster, h.stats.writeStatAsPeer, h.stats.writeAmplification)
 			if srcRegion != nil {
 				schedulerCounter.WithLabelValues(h.GetName(), "move_peer").Inc()
-				return []*schedule.Operator{schedule.CreateMovePeerOperator("moveHotWriteRegion", cluster, srcRegion, schedule.OpHotRegion, srcPeer.GetStoreId(), destPeer.GetStoreId(), destPeer.GetId())}
+				op := schedule.CreateMovePeerOperator("moveHotWriteRegion", cluster, srcRegion, schedule.OpHotRegion, srcPeer.GetStoreId(), destPeer.GetStoreId(), destPeer.GetId())
+				op.SetDetail(hotOperatorDetail(srcRegion.GetID(), h.stats.writeStatAsPeer, srcPeer.GetStoreId(), destPeer.GetStoreId()))
+				h.tagOperator(op, hotWriteRegionBalance, "move-peer")
+				h.recordDecision(hotWriteRegionBalance, srcRegion, h.stats.writeStatAsPeer, srcPeer.GetStoreId(), destPeer.GetStoreId())
+				h.addPendingInfluence(op, hotWriteRegionBalance, h.stats.writeStatAsPeer, srcPeer.GetStoreId(), destPeer.GetStoreId())
+				return []*schedule.Operator{op}
 			}
 		case 1:
 			// balance by leader
-			srcRegion, newLeader := h.balanceByLeader(cluster, h.stats.writeStatAsLeader)
+			srcRegion, newLeader := h.balanceByLeader(cluster, h.stats.writeStatAsLeader, h.stats.writeAmplification)
 			if srcRegion != nil {
 				schedulerCounter.WithLabelValues(h.GetName(), "move_leader").Inc()
 				step := schedule.TransferLeader{FromStore: srcRegion.GetLeader().GetStoreId(), ToStore: newLeader.GetStoreId()}
-				return []*schedule.Operator{schedule.NewOperator("transferHotWriteLeader", srcRegion.GetID(), srcRegion.GetRegionEpoch(), schedule.OpHotRegion|schedule.OpLeader, step)}
+				op := schedule.NewOperator("transferHotWriteLeader", srcRegion.GetID(), srcRegion.GetRegionEpoch(), schedule.OpHotRegion|schedule.OpLeader, step)
+				op.SetDetail(hotOperatorDetail(srcRegion.GetID(), h.stats.writeStatAsLeader, step.FromStore, step.ToStore))
+				h.tagOperator(op, hotWriteRegionBalance, "transfer-leader")
+				h.reportDecision(srcRegion.GetID(), step, step.FromStore, step.ToStore)
+				h.recordDecision(hotWriteRegionBalance, srcRegion, h.stats.writeStatAsLeader, step.FromStore, step.ToStore)
+				h.addPendingInfluence(op, hotWriteRegionBalance, h.stats.writeStatAsLeader, step.FromStore, step.ToStore)
+				return []*schedule.Operator{op}
 			}
 		}
 	}
 
 	schedulerCounter.WithLabelValues(h.GetName(), "skip").Inc()
+	h.summarizeSkip()
 	return nil
 }
 
-func (h *balanceHotRegionsScheduler) calcScore(items []*core.RegionStat, cluster schedule.Cluster, kind core.ResourceKind) core.StoreHotRegionsStat {
-	stats := make(core.StoreHotRegionsStat)
-	for _, r := range items {
-		if r.HotDegree < cluster.GetHotRegionLowThreshold() {
-			continue
-		}
+// hotOperatorDetail describes the region's smoothed flow and the source and
+// destination stores in a fixed format, e.g. "{r=88, 42MiB/s, s3→s7}".
+func hotOperatorDetail(regionID uint64, storesStat core.StoreHotRegionsStat, srcStoreID, destStoreID uint64) string {
+	flowBytes := hotRegionFlowBytes(regionID, storesStat, srcStoreID)
+	return fmt.Sprintf("{r=%d, %s/s, s%d→s%d}", regionID, strings.Replace(gh.IBytes(flowBytes), " ", "", 1), srcStoreID, destStoreID)
+}
 
-		regionInfo := cluster.GetRegion(r.RegionID)
-		if regionInfo == nil {
-			continue
-		}
+// hotRegionFlowBytes returns the smoothed flow bytes of the region in the
+// source store, 0 if it is not hot there.
+func hotRegionFlowBytes(regionID uint64, storesStat core.StoreHotRegionsStat, srcStoreID uint64) uint64 {
+	if rs := hotRegionStat(regionID, storesStat, srcStoreID); rs != nil {
+		return rs.FlowBytes
+	}
+	return 0
+}
 
-		var storeIDs []uint64
-		switch kind {
-		case core.RegionKind:
-			for id := range regionInfo.GetStoreIds() {
-				storeIDs = append(storeIDs, id)
+// hotRegionStat returns the statistics of the region in the source store,
+// nil if it is not hot there.
+func hotRegionStat(regionID uint64, storesStat core.StoreHotRegionsStat, srcStoreID uint64) *core.RegionStat {
+	if stat, ok := storesStat[srcStoreID]; ok {
+		for i := range stat.RegionsStat {
+			if stat.RegionsStat[i].RegionID == regionID {
+				return &stat.RegionsStat[i]
 			}
-		case core.LeaderKind:
-			storeIDs = append(storeIDs, regionInfo.GetLeader().GetStoreId())
 		}
+	}
+	return nil
+}
 
-		for _, storeID := range storeIDs {
-			storeStat, ok := stats[storeID]
-			if !ok {
-				storeStat = &core.HotRegionsStat{
-					RegionsStat: make(core.RegionsStat, 0, storeHotRegionsDefaultLen),
-				}
-				stats[storeID] = storeStat
-			}
-
-			s := core.RegionStat{
-				RegionID:       r.RegionID,
-				FlowBytes:      uint64(r.Stats.Median()),
-				HotDegree:      r.HotDegree,
-				LastUpdateTime: r.LastUpdateTime,
-				StoreID:        storeID,
-				AntiCount:      r.AntiCount,
-				Version:        r.Version,
-			}
-			storeStat.TotalFlowBytes += r.FlowBytes
-			storeStat.RegionsCount++
-			storeStat.RegionsStat = append(storeStat.RegionsStat, s)
+// tagOperator attaches the configured tags and the decision context to the operator.
+func (h *balanceHotRegionsScheduler) tagOperator(op *schedule.Operator, typ BalanceType, reason string) {
+	for k, v := range h.tags {
+		op.SetTag(k, v)
+	}
+	op.SetTag(hotTagBalanceType, typ.String())
+	op.SetTag(hotTagDimension, h.dimension.String())
+	op.SetTag(hotTagReason, reason)
+	if len(h.rankedCandidates) != 0 {
+		ids := make([]string, 0, len(h.rankedCandidates))
+		for _, id := range h.rankedCandidates {
+			ids = append(ids, strconv.FormatUint(id, 10))
 		}
+		op.SetTag(hotTagCandidates, strings.Join(ids, ","))
+	}
+	if h.relaxed {
+		op.SetTag(hotTagRelaxed, relaxableConstraintsString())
+	}
+	if len(h.filterStats) != 0 {
+		op.SetTag(hotTagFilterRejections, h.filterStats.String())
+	}
+	if len(h.suspectStores) != 0 {
+		op.SetTag(hotTagSuspectStores, h.suspectStoresString())
+	}
+	h.summary = op.GetTags()
+	h.summary["operator"] = op.String()
+	if h.limitInputs != "" {
+		h.summary[hotSummaryLimit] = h.limitInputs
+	}
+}
+
+// summarizeSkip records why the current dispatch created no operator.
+func (h *balanceHotRegionsScheduler) summarizeSkip() {
+	h.summary[hotTagReason] = "skip"
+	if len(h.filterStats) != 0 {
+		h.summary[hotTagFilterRejections] = h.filterStats.String()
+	}
+	if len(h.suspectStores) != 0 {
+		h.summary[hotTagSuspectStores] = h.suspectStoresString()
+	}
+}
+
+// GetSummary implements schedule.SummaryProvider, it returns the decision
+// context of the last dispatch, which is the same as the tags of the created
+// operator if there is one.
+func (h *balanceHotRegionsScheduler) GetSummary() map[string]string {
+	h.RLock()
+	defer h.RUnlock()
+	summary := make(map[string]string, len(h.summary))
+	for k, v := range h.summary {
+		summary[k] = v
+	}
+	return summary
+}
+
+// LastBalanceType returns the balance type of the last dispatch, false if
+// there is none.
+func (h *balanceHotRegionsScheduler) LastBalanceType() (BalanceType, bool) {
+	h.RLock()
+	defer h.RUnlock()
+	if h.summary == nil {
+		return 0, false
+	}
+	return h.balanceType, true
+}
+
+// updateMinRegionFlowBytes follows the minimal flow bytes configured in the
+// cluster unless it is passed at registration or set by HTTP.
+func (h *balanceHotRegionsScheduler) updateMinRegionFlowBytes(cluster schedule.Cluster) {
+	flowBytes, reason := h.minRegionFlowBytesOf(cluster)
+	if err := h.setTunable("min-region-flow-bytes", flowBytes, core.HotChangeSourceInternal, reason); err != nil {
+		log.Errorf("[%s] failed to change the minimal region flow bytes: %v", h.GetName(), err)
+	}
+}
+
+// minRegionFlowBytesOf returns the minimal region flow bytes the cluster
+// configuration or the override asks for, and why.
+func (h *balanceHotRegionsScheduler) minRegionFlowBytesOf(cluster schedule.Cluster) (uint64, string) {
+	if h.minRegionFlowBytesOverride != nil {
+		return *h.minRegionFlowBytesOverride, "follow the overridden min-region-flow-bytes"
+	}
+	return cluster.GetHotRegionMinFlowBytes(), "follow the cluster configuration hot-region-min-flow-bytes"
+}
+
+func (h *balanceHotRegionsScheduler) calcScore(items []*core.RegionStat, cluster schedule.Cluster, kind core.ResourceKind) core.StoreHotRegionsStat {
+	return h.calcStoreStats(items, cluster, kind, false)
+}
+
+// calcScoreByKeys is calcScore for balancing on the flow keys, the regions
+// below minRegionFlowBytes are kept as candidates.
+func (h *balanceHotRegionsScheduler) calcScoreByKeys(items []*core.RegionStat, cluster schedule.Cluster, kind core.ResourceKind) core.StoreHotRegionsStat {
+	return h.calcStoreStats(items, cluster, kind, true)
+}
+
+func (h *balanceHotRegionsScheduler) calcStoreStats(items []*core.RegionStat, cluster schedule.Cluster, kind core.ResourceKind, byKeys bool) core.StoreHotRegionsStat {
+	span, finish := h.startSpan(hotSpanCalcScore)
+	defer finish()
+	defer h.observeStage(hotStageCalcScore, time.Now())
+	setSpanTag(span, hotSpanTagKind, kind.String())
+	minRegionFlowBytes := h.minRegionFlowBytes
+	if h.preview {
+		// The preview doesn't record the change, see dispatchLocked.
+		minRegionFlowBytes, _ = h.minRegionFlowBytesOf(cluster)
+	}
+	if byKeys {
+		minRegionFlowBytes = 0
+	}
+	items = h.clampStats(items)
+	items = h.filterKeyRange(items, cluster)
+	stats, staleCount, unhealthyCount := summarizeHotRegions(items, cluster, kind, cluster.GetHotRegionLowThreshold(), minRegionFlowBytes)
+	if staleCount > 0 {
+		schedulerCounter.WithLabelValues(h.GetName(), "stale_version_dropped").Add(float64(staleCount))
+	}
+	if unhealthyCount > 0 {
+		schedulerCounter.WithLabelValues(h.GetName(), "unhealthy_dropped").Add(float64(unhealthyCount))
 	}
 	return stats
 }
 
-func (h *balanceHotRegionsScheduler) balanceByPeer(cluster schedule.Cluster, storesStat core.StoreHotRegionsStat) (*core.RegionInfo, *metapb.Peer, *metapb.Peer) {
-	if !h.allowBalanceRegion(cluster) {
+// selectDimension chooses the dimension of the current decision unless it is
+// pinned.
+func (h *balanceHotRegionsScheduler) selectDimension(storesStat core.StoreHotRegionsStat) {
+	if h.pinDimension {
+		return
+	}
+	if h.keysWeight > 0 && !h.disabledByVersionGate(hotFeatureKeysFlow) {
+		h.dimension, h.bytesPerKey = weightedDimension, bytesPerKey(storesStat)
+		return
+	}
+	h.dimension = selectBalanceDimension(storesStat)
+}
+
+// storeFlow returns the total flow of the hot regions of a store in the
+// dimension of the current decision.
+func (h *balanceHotRegionsScheduler) storeFlow(stat *core.HotRegionsStat) uint64 {
+	if h.dimension == weightedDimension {
+		return h.weightedFlow(stat.TotalFlowBytes, stat.TotalFlowKeys)
+	}
+	return h.dimension.storeFlow(stat)
+}
+
+// regionFlow returns the flow of a hot region in the dimension of the current
+// decision.
+func (h *balanceHotRegionsScheduler) regionFlow(stat *core.RegionStat) uint64 {
+	if h.dimension == weightedDimension {
+		return h.weightedFlow(stat.FlowBytes, stat.FlowKeys)
+	}
+	return h.dimension.regionFlow(stat)
+}
+
+// weightedFlow combines the flow bytes and the flow keys by keysWeight, the
+// flow keys are converted to bytes by bytesPerKey so that both are in the
+// same unit.
+func (h *balanceHotRegionsScheduler) weightedFlow(flowBytes, flowKeys uint64) uint64 {
+	return uint64((1-h.keysWeight)*float64(flowBytes) + h.keysWeight*float64(flowKeys)*h.bytesPerKey)
+}
+
+// validateKeysWeight checks the weight of the flow keys is in [0, 1].
+func validateKeysWeight(weight float64) error {
+	if !(weight >= 0 && weight <= 1) {
+		return errors.Errorf("invalid keys weight %v, it must be in [0, 1]", weight)
+	}
+	return nil
+}
+
+// validateRetryLimit checks the limit to retry schedule is in [1, 100].
+func validateRetryLimit(limit int) error {
+	if limit < 1 || limit > maxHotRetryLimit {
+		return errors.Errorf("invalid retry limit %d, it must be in [1, %d]", limit, maxHotRetryLimit)
+	}
+	return nil
+}
+
+func (h *balanceHotRegionsScheduler) balanceByPeer(cluster schedule.Cluster, storesStat core.StoreHotRegionsStat, writeAmplification map[uint64]float64) (*core.RegionInfo, *metapb.Peer, *metapb.Peer) {
+	defer h.observeStage(hotStageBalanceByPeer, time.Now())
+	if !h.allowBalanceRegion(cluster, h.balanceType) {
 		return nil, nil, nil
 	}
+	storesStat = h.withPendingInfluence(storesStat)
 
-	srcStoreID := h.selectSrcStore(storesStat)
+	h.selectDimension(storesStat)
+	srcStoreID := h.selectSrcStore(storesStat, writeAmplification)
 	if srcStoreID == 0 {
 		return nil, nil, nil
 	}
 
+	if srcRegion, srcPeer, destPeer := h.balancePeerFrom(cluster, srcStoreID, storesStat, false); srcRegion != nil {
+		return srcRegion, srcPeer, destPeer
+	}
+	if !h.isCriticalSource(storesStat[srcStoreID]) {
+		return nil, nil, nil
+	}
+	srcRegion, srcPeer, destPeer := h.balancePeerFrom(cluster, srcStoreID, storesStat, true)
+	if srcRegion != nil {
+		h.recordRelaxed(srcRegion, srcStoreID, destPeer.GetStoreId())
+	}
+	return srcRegion, srcPeer, destPeer
+}
+
+// balancePeerFrom tries to move a hot region peer out of the source store,
+// the relaxable constraints are ignored if relaxed is true.
+func (h *balanceHotRegionsScheduler) balancePeerFrom(cluster schedule.Cluster, srcStoreID uint64, storesStat core.StoreHotRegionsStat, relaxed bool) (*core.RegionInfo, *metapb.Peer, *metapb.Peer) {
 	// get one source region and a target store.
 	// For each region in the source store, we try to find the best target store;
 	// If we can find a target store, then return from this method.
+	span, finish := h.startSpan(hotSpanBalancePeer)
+	defer finish()
+	setSpanTag(span, hotSpanTagSrcStore, srcStoreID)
+	setSpanTag(span, hotSpanTagRelaxed, relaxed)
 	stores := cluster.GetStores()
 	var destStoreID uint64
-	for _, i := range h.r.Perm(storesStat[srcStoreID].RegionsStat.Len()) {
+	perm := h.r.Perm(storesStat[srcStoreID].RegionsStat.Len())
+	for k, i := range perm {
 		rs := storesStat[srcStoreID].RegionsStat[i]
 		srcRegion := cluster.GetRegion(rs.RegionID)
 		if srcRegion == nil || len(srcRegion.GetDownPeers()) != 0 || len(srcRegion.GetPendingPeers()) != 0 {
 			continue
 		}
+		// The scan-heavy region is left to splitting.
+		if rs.SplitCandidate {
+			schedulerCounter.WithLabelValues(h.GetName(), "skip_split_candidate").Inc()
+			continue
+		}
+		if h.inCooldown(rs.RegionID) {
+			schedulerCounter.WithLabelValues(h.GetName(), "skip_cooldown").Inc()
+			continue
+		}
+		if h.isPending(rs.RegionID) {
+			schedulerCounter.WithLabelValues(h.GetName(), "skip_pending").Inc()
+			continue
+		}
+		if h.balanceType == hotReadRegionBalance && h.adviseFollowerRead(cluster, srcRegion, storesStat) {
+			continue
+		}
+		if h.hasDeadSource(cluster, srcRegion) {
+			continue
+		}
+		h.traceRegion(rs.RegionID)
 
 		srcStore := cluster.GetStore(srcStoreID)
 		filters := []schedule.Filter{
 			schedule.StoreStateFilter{MoveRegion: true},
 			schedule.NewExcludedFilter(srcRegion.GetStoreIds(), srcRegion.GetStoreIds()),
-			schedule.NewDistinctScoreFilter(cluster.GetLocationLabels(), cluster.GetRegionStores(srcRegion), srcStore),
+		}
+		if len(h.excludedTargets) != 0 {
+			filters = append(filters, schedule.NewExcludedFilter(nil, h.excludedTargets))
+		}
+		if filter := h.applyLoadFilter(cluster); filter != nil {
+			filters = append(filters, filter)
+		}
+		if !relaxed {
+			filters = append(filters, schedule.NewDistinctScoreFilter(cluster.GetLocationLabels(), cluster.GetRegionStores(srcRegion), srcStore))
 		}
 		destStoreIDs := make([]uint64, 0, len(stores))
 		for _, store := range stores {
-			if schedule.FilterTarget(cluster, store, filters) {
+			if h.filterTarget(cluster, store, filters) {
 				continue
 			}
 			destStoreIDs = append(destStoreIDs, store.GetId())
 		}
 
-		destStoreID, _ = h.selectDestStore(destStoreIDs, rs.FlowBytes, srcStoreID, storesStat)
+		selection := h.selectDestStore(destStoreIDs, h.regionFlow(&rs), srcStoreID, storesStat, relaxed)
+		present := make(map[string]string)
+		if relaxed {
+			present[featureRelaxed] = relaxableConstraintsString()
+		}
+		if load, ok := h.applyLoad(cluster, selection.StoreID); ok {
+			present[featureDestApplyLoad] = strconv.FormatFloat(load, 'f', 2, 64)
+		}
+		features, ranked := h.appendDecisionFeatures(selection.Features, present), selection.Ranked
+		// The model predicts the leader transfers only, see
+		// predictionKeyPattern, so the peer moves follow the heuristics.
+		destStoreID = selection.StoreID
+		h.logDecision(hotDecisionRecord{
+			RegionID:      rs.RegionID,
+			SourceStoreID: srcStoreID,
+			Candidates:    h.decisionCandidates(destStoreIDs, storesStat),
+			DestStoreID:   destStoreID,
+			Strategy:      selection.Strategy,
+			Relaxed:       relaxed,
+			Features:      features,
+		})
 		if destStoreID != 0 {
-			h.adjustBalanceLimit(srcStoreID, storesStat)
-
+			// The region may be changing its membership, try the next one.
 			srcPeer := srcRegion.GetStorePeer(srcStoreID)
 			if srcPeer == nil {
-				return nil, nil, nil
+				log.Warnf("hot region %d has no peer on source store %d, skip it", srcRegion.GetID(), srcStoreID)
+				continue
 			}
 
+			h.adjustBalanceLimit(h.balanceType, srcStoreID, storesStat)
+
 			// When the target store is decided, we allocate a peer ID to hold the source region,
 			// because it doesn't exist in the system right now.
 			destPeer, err := cluster.AllocPeer(destStoreID)
 			if err != nil {
 				log.Errorf("failed to allocate peer: %v", err)
+				setSpanTag(span, hotSpanTagOutcome, "alloc-peer-failed")
 				return nil, nil, nil
 			}
 
+			h.rankedCandidates = ranked
+			h.features = features
+			h.expediteIfMovable(cluster, storesStat[srcStoreID].RegionsStat, perm[k+1:])
+			setSpanTag(span, hotSpanTagRegion, srcRegion.GetID())
+			setSpanTag(span, hotSpanTagDestStore, destStoreID)
+			setSpanTag(span, hotSpanTagOutcome, "found")
 			return srcRegion, srcPeer, destPeer
 		}
 	}
 
+	setSpanTag(span, hotSpanTagOutcome, "not-found")
 	return nil, nil, nil
 }
 
-func (h *balanceHotRegionsScheduler) balanceByLeader(cluster schedule.Cluster, storesStat core.StoreHotRegionsStat) (*core.RegionInfo, *metapb.Peer) {
-	if !h.allowBalanceLeader(cluster) {
+func (h *balanceHotRegionsScheduler) balanceByLeader(cluster schedule.Cluster, storesStat core.StoreHotRegionsStat, writeAmplification map[uint64]float64) (*core.RegionInfo, *metapb.Peer) {
+	defer h.observeStage(hotStageBalanceByLeader, time.Now())
+	if !h.allowBalanceLeader(cluster, h.balanceType) {
 		return nil, nil
 	}
+	storesStat = h.withPendingInfluence(storesStat)
 
-	srcStoreID := h.selectSrcStore(storesStat)
+	h.selectDimension(storesStat)
+	srcStoreID := h.selectSrcStore(storesStat, writeAmplification)
 	if srcStoreID == 0 {
 		return nil, nil
 	}
 
+	if srcRegion, destPeer := h.balanceLeaderFrom(cluster, srcStoreID, storesStat, false); srcRegion != nil {
+		return srcRegion, destPeer
+	}
+	if !h.isCriticalSource(storesStat[srcStoreID]) {
+		return nil, nil
+	}
+	srcRegion, destPeer := h.balanceLeaderFrom(cluster, srcStoreID, storesStat, true)
+	if srcRegion != nil {
+		h.recordRelaxed(srcRegion, srcStoreID, destPeer.GetStoreId())
+	}
+	return srcRegion, destPeer
+}
+
+// balanceLeaderFrom tries to transfer a hot region leader out of the source
+// store, the relaxable constraints are ignored if relaxed is true.
+func (h *balanceHotRegionsScheduler) balanceLeaderFrom(cluster schedule.Cluster, srcStoreID uint64, storesStat core.StoreHotRegionsStat, relaxed bool) (*core.RegionInfo, *metapb.Peer) {
+	span, finish := h.startSpan(hotSpanBalanceLeader)
+	defer finish()
+	setSpanTag(span, hotSpanTagSrcStore, srcStoreID)
+	setSpanTag(span, hotSpanTagRelaxed, relaxed)
 	// select destPeer
-	for _, i := range h.r.Perm(storesStat[srcStoreID].RegionsStat.Len()) {
+	perm := h.r.Perm(storesStat[srcStoreID].RegionsStat.Len())
+	for k, i := range perm {
 		rs := storesStat[srcStoreID].RegionsStat[i]
 		srcRegion := cluster.GetRegion(rs.RegionID)
 		if srcRegion == nil || len(srcRegion.GetDownPeers()) != 0 || len(srcRegion.GetPendingPeers()) != 0 {
 			continue
 		}
+		if rs.SplitCandidate {
+			schedulerCounter.WithLabelValues(h.GetName(), "skip_split_candidate").Inc()
+			continue
+		}
+		if h.inCooldown(rs.RegionID) {
+			schedulerCounter.WithLabelValues(h.GetName(), "skip_cooldown").Inc()
+			continue
+		}
+		if h.isPending(rs.RegionID) {
+			schedulerCounter.WithLabelValues(h.GetName(), "skip_pending").Inc()
+			continue
+		}
+		if h.balanceType == hotReadRegionBalance && h.adviseFollowerRead(cluster, srcRegion, storesStat) {
+			continue
+		}
+		if h.hasDeadSource(cluster, srcRegion) {
+			continue
+		}
+		h.traceRegion(rs.RegionID)
 
 		filters := []schedule.Filter{schedule.StoreStateFilter{TransferLeader: true}}
+		if len(h.excludedTargets) != 0 {
+			filters = append(filters, schedule.NewExcludedFilter(nil, h.excludedTargets))
+		}
 		candidateStoreIDs := make([]uint64, 0, len(srcRegion.GetPeers())-1)
 		for _, store := range cluster.GetFollowerStores(srcRegion) {
-			if !schedule.FilterTarget(cluster, store, filters) {
+			if !h.filterTarget(cluster, store, filters) {
 				candidateStoreIDs = append(candidateStoreIDs, store.GetId())
 			}
 		}
+		candidateStoreIDs = h.filterLeaderLabel(cluster, candidateStoreIDs)
 		if len(candidateStoreIDs) == 0 {
 			continue
 		}
-		destStoreID, mstr := h.selectDestStore(candidateStoreIDs, rs.FlowBytes, srcStoreID, storesStat)
-		postJSON("", mstr, srcStoreID, destStoreID)
+		selection := h.selectDestStore(candidateStoreIDs, h.regionFlow(&rs), srcStoreID, storesStat, relaxed)
+		present := map[string]string{featureFlowBytesP99: strconv.FormatUint(rs.FlowBytesP99, 10)}
+		if h.leaderLabel.Key != "" {
+			present[featureLeaderLabel] = fmt.Sprintf("%s=%s", h.leaderLabel.Key, h.leaderLabel.Value)
+		}
+		if relaxed {
+			present[featureRelaxed] = relaxableConstraintsString()
+		}
+		if rs.HasReadBreakdown() {
+			present[featureCoprocessorFlowBytes] = strconv.FormatUint(rs.CoprocessorFlowBytes, 10)
+			present[featureGetFlowBytes] = strconv.FormatUint(rs.GetFlowBytes, 10)
+		}
+		destStoreID, mstr, ranked := selection.StoreID, h.appendDecisionFeatures(selection.Features, present), selection.Ranked
+		var prediction *Prediction
+		if destStoreID != 0 {
+			destStoreID, prediction = h.predictDestStore(rs.RegionID, mstr, srcStoreID, destStoreID, candidateStoreIDs, ranked)
+		}
+		h.logDecision(hotDecisionRecord{
+			RegionID:      rs.RegionID,
+			SourceStoreID: srcStoreID,
+			Candidates:    h.decisionCandidates(candidateStoreIDs, storesStat),
+			DestStoreID:   destStoreID,
+			Strategy:      selection.Strategy,
+			Relaxed:       relaxed,
+			Features:      mstr,
+			Prediction:    newDecisionPrediction(prediction),
+		})
 		if destStoreID == 0 {
 			continue
 		}
 
 		destPeer := srcRegion.GetStoreVoter(destStoreID)
 		if destPeer != nil {
-			h.adjustBalanceLimit(srcStoreID, storesStat)
-			step := schedule.TransferLeader{FromStore: srcRegion.GetLeader().GetStoreId(), ToStore: destPeer.GetStoreId()}
-			postJSON(step.String(), mstr, srcStoreID, destStoreID)
+			h.adjustBalanceLimit(h.balanceType, srcStoreID, storesStat)
+			h.rankedCandidates = ranked
+			h.features = mstr
+			h.expediteIfMovable(cluster, storesStat[srcStoreID].RegionsStat, perm[k+1:])
+			setSpanTag(span, hotSpanTagRegion, srcRegion.GetID())
+			setSpanTag(span, hotSpanTagDestStore, destStoreID)
+			setSpanTag(span, hotSpanTagOutcome, "found")
 			return srcRegion, destPeer
 		}
 	}
+	setSpanTag(span, hotSpanTagOutcome, "not-found")
 	return nil, nil
 }
 
-func postJSON(s string, ms []Feature, srcStoreID, destStoreID uint64) {
-	if s == "" || ms == nil {
+// expediteIfMovable asks for an expedited run after the operator of the
+// current dispatch is created, if any of the hot regions of the source store
+// not tried yet passes the checks of the regions, i.e. more hot regions are
+// found than the operator moves.
+func (h *balanceHotRegionsScheduler) expediteIfMovable(cluster schedule.Cluster, regions core.RegionsStat, rest []int) {
+	if h.preview {
 		return
 	}
-	b, err := json.Marshal(ms)
-	if err != nil {
-		log.Println(err)
+	for _, i := range rest {
+		if h.isMovable(cluster, &regions[i]) {
+			h.expedite = true
+			return
+		}
 	}
+}
 
-	step := "[" + "\"" + s + "\"" + ","
-	str := "{\"updates\":[" + step + string(b) + "],"
-
-	str = str[:len(str)-1]
-	str = str + "]}"
+// isMovable checks the hot region as balancePeerFrom and balanceLeaderFrom
+// do before selecting its destination, without recording anything.
+func (h *balanceHotRegionsScheduler) isMovable(cluster schedule.Cluster, rs *core.RegionStat) bool {
+	region := cluster.GetRegion(rs.RegionID)
+	if region == nil || len(region.GetDownPeers()) != 0 || len(region.GetPendingPeers()) != 0 {
+		return false
+	}
+	if rs.SplitCandidate || h.inCooldown(rs.RegionID) || h.isPending(rs.RegionID) {
+		return false
+	}
+	store := cluster.GetStore(region.GetLeader().GetStoreId())
+	return store != nil && !store.IsTombstone() && store.DownTime() <= cluster.GetMaxStoreDownTime()
+}
 
-	// PUT model service
-	httpClient("PUT", str, srcStoreID, destStoreID)
+// isCriticalSource checks if the flow bytes of the source store is above the critical threshold.
+func (h *balanceHotRegionsScheduler) isCriticalSource(stat *core.HotRegionsStat) bool {
+	return h.criticalFlowBytes > 0 && stat != nil && stat.TotalFlowBytes >= h.criticalFlowBytes
+}
 
-	// POST model
-	gstr := "{\"features\": [" + string(b) + "]}"
-	httpClient("POST", gstr, srcStoreID, destStoreID)
+func (h *balanceHotRegionsScheduler) recordRelaxed(region *core.RegionInfo, srcStoreID, destStoreID uint64) {
+	h.relaxed = true
+	schedulerCounter.WithLabelValues(h.GetName(), "relaxed").Inc()
+	log.Infof("[%s] relax constraints %v to move hot region %d from critical store %d to store %d",
+		h.GetName(), relaxableConstraints, region.GetID(), srcStoreID, destStoreID)
 }
 
-var reqURL = "http://106.75.11.4:8000/model/xxx1"
+// filterLeaderLabel keeps the candidate stores matching the leader label.
+// All candidates are kept if none of them matches and the constraint is not strict.
+func (h *balanceHotRegionsScheduler) filterLeaderLabel(cluster schedule.Cluster, candidateStoreIDs []uint64) []uint64 {
+	if h.leaderLabel.Key == "" {
+		return candidateStoreIDs
+	}
+	matched := make([]uint64, 0, len(candidateStoreIDs))
+	for _, id := range candidateStoreIDs {
+		store := cluster.GetStore(id)
+		if store != nil && strings.EqualFold(store.GetLabelValue(h.leaderLabel.Key), h.leaderLabel.Value) {
+			matched = append(matched, id)
+		}
+	}
+	if len(matched) == 0 && !h.leaderLabel.Strict {
+		return candidateStoreIDs
+	}
+	return matched
+}
 
-func httpClient(method, jsonStr string, srcStoreID, destStoreID uint64) {
-	logStr := "[HT]method:" + method + ", URL:>" + reqURL
+// hasDeadSource returns true if the store of the region leader is down or
+// gone. The leader sends the snapshot to the new peer and transfers the
+// leadership, so the operator could never complete and would only tie up an
+// operator slot. A down store of another peer is fine, the peer is removed
+// by the move.
+func (h *balanceHotRegionsScheduler) hasDeadSource(cluster schedule.Cluster, region *core.RegionInfo) bool {
+	store := cluster.GetStore(region.GetLeader().GetStoreId())
+	if store != nil && !store.IsTombstone() && store.DownTime() <= cluster.GetMaxStoreDownTime() {
+		return false
+	}
+	log.Debugf("[%s] the leader store of hot region %d is down, skip it", h.GetName(), region.GetID())
+	schedulerCounter.WithLabelValues(h.GetName(), "dead_source_skip").Inc()
+	return true
+}
 
-	req, err := http.NewRequest(method, reqURL, strings.NewReader(jsonStr))
-	req.Header.Set("Content-Type", "application/json")
+// Select the store to move hot regions from, which has at least
+// minHotRegionCount hot regions.
+// We choose the store with the maximum number of hot region first.
+// Inside these stores, we choose the one with maximum flow in the dimension.
+// The number of hot regions only counts the movable regions, which carry at
+// least minRegionFlowBytes, while the flow bytes include all hot regions.
+// If writeAmplification is not nil, the store with the maximum estimated IO
+// load, which is the flow bytes multiplied by the write amplification, is
+// chosen instead, so the IO saturated stores are drained first.
+// The stores with suspect statistics and the excluded stores are skipped.
+func (h *balanceHotRegionsScheduler) selectSrcStore(stats core.StoreHotRegionsStat, writeAmplification map[uint64]float64) (srcStoreID uint64) {
+	defer func() {
+		h.observeSelection(hotSelectionSource, srcStoreID, stats[srcStoreID])
+	}()
+	if len(h.suspectStores) != 0 || len(h.excludedStores) != 0 {
+		trusted := make(core.StoreHotRegionsStat, len(stats))
+		for storeID, stat := range stats {
+			_, suspect := h.suspectStores[storeID]
+			_, excluded := h.excludedStores[storeID]
+			if !suspect && !excluded {
+				trusted[storeID] = stat
+			}
+		}
+		stats = trusted
+	}
+	if writeAmplification != nil {
+		return selectSrcStoreByIOLoad(stats, writeAmplification, h.minHotRegionCount)
+	}
 
-	resp, err := http.DefaultClient.Do(req)
+	var (
+		maxFlow                uint64
+		maxHotStoreRegionCount int
+	)
 
-	if resp == nil || err != nil {
-		log.Println("[HOT] http request error or resp is nil, ", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	body, _ := ioutil.ReadAll(resp.Body)
-	headStr := fmt.Sprintf("%v", resp.Header)
-	logStr += ", response Status:" + resp.Status + ", response Headers:" + headStr + ", response Body:" + string(body)
-	if strings.Contains(string(body), "predictions") {
-		var maxProbability float64
-		var v map[string][]interface{}
-		json.Unmarshal(body, &v)
-		v2 := v["predictions"]
-		var ke string
-		for k, v := range v2[0].(map[string]interface{}) {
-			if maxProbability < v.(float64) {
-				maxProbability = v.(float64)
-				ke = k
-			}
-		}
-		logStr += "\nsuggest step: " + ke + ", maxProbability:" + fmt.Sprintf("%.15f", maxProbability)
-		// suggest step: transfer leader from store 7 to store 2, maxProbability:0.432223661517613
-		srcStoreIDD, _ := strconv.Atoi(ke[27:28])
-		destStoreIDD, _ := strconv.Atoi(ke[38:39])
-		if srcStoreID == uint64(srcStoreIDD) && destStoreID == uint64(destStoreIDD) {
-			logStr += "-[HIT]"
-		} else {
-			logStr += "-[MISS], srcStoreID:" + strconv.Itoa(int(srcStoreID)) + ",destStoreID:" + strconv.Itoa(int(destStoreID))
+	for storeID, statistics := range stats {
+		count, flow := statistics.RegionsStat.Len(), h.storeFlow(statistics)
+		if count >= h.minHotRegionCount && (count > maxHotStoreRegionCount || (count == maxHotStoreRegionCount && flow > maxFlow)) {
+			maxHotStoreRegionCount = count
+			maxFlow = flow
+			srcStoreID = storeID
 		}
 	}
-	log.Println(logStr)
+	return
 }
 
-// Select the store to move hot regions from.
-// We choose the store with the maximum number of hot region first.
-// Inside these stores, we choose the one with maximum flow bytes.
-func (h *balanceHotRegionsScheduler) selectSrcStore(stats core.StoreHotRegionsStat) (srcStoreID uint64) {
+func selectSrcStoreByIOLoad(stats core.StoreHotRegionsStat, writeAmplification map[uint64]float64, minHotRegionCount int) (srcStoreID uint64) {
 	var (
-		maxFlowBytes           uint64
+		maxIOLoad              float64
 		maxHotStoreRegionCount int
 	)
 
 	for storeID, statistics := range stats {
-		count, flowBytes := statistics.RegionsStat.Len(), statistics.TotalFlowBytes
-		if count >= 2 && (count > maxHotStoreRegionCount || (count == maxHotStoreRegionCount && flowBytes > maxFlowBytes)) {
+		count := statistics.RegionsStat.Len()
+		amplification, ok := writeAmplification[storeID]
+		if !ok {
+			amplification = 1
+		}
+		ioLoad := float64(statistics.TotalFlowBytes) * amplification
+		if count >= minHotRegionCount && (ioLoad > maxIOLoad || (ioLoad == maxIOLoad && count > maxHotStoreRegionCount)) {
+			maxIOLoad = ioLoad
 			maxHotStoreRegionCount = count
-			maxFlowBytes = flowBytes
 			srcStoreID = storeID
 		}
 	}
 	return
 }
 
+// isScanHot returns true if the read flow of the region is mainly caused by
+// the coprocessor scans. It is false if the breakdown is not reported.
+func isScanHot(stat *core.RegionStat) bool {
+	if !stat.HasReadBreakdown() {
+		return false
+	}
+	return float64(stat.CoprocessorFlowBytes) >= float64(stat.CoprocessorFlowBytes+stat.GetFlowBytes)*hotScanFlowRatio
+}
+
+// checkSuspectStats compares the hot region flow of the stores with the flow
+// reported by the store heartbeats, the stores whose ratio of them exceeds
+// suspectStatsFactor are suspect and not used as the source. The ratio is
+// calibrated by the lower median ratio of the stores if it is above 1, so a
+// systematic unit or interval difference between the region and the store
+// statistics is tolerated.
+func (h *balanceHotRegionsScheduler) checkSuspectStats(cluster schedule.Cluster, stats core.StoreHotRegionsStat, storeFlow func(*core.StoreInfo) float64) map[uint64]float64 {
+	if h.suspectStatsFactor == 0 {
+		return nil
+	}
+	ratios := make(map[uint64]float64, len(stats))
+	sorted := make([]float64, 0, len(stats))
+	for storeID, stat := range stats {
+		store := cluster.GetStore(storeID)
+		if store == nil || stat.TotalFlowBytes == 0 {
+			continue
+		}
+		// The store flow is unavailable.
+		flowBytes := storeFlow(store)
+		if flowBytes <= 0 {
+			continue
+		}
+		ratio := safeDiv(float64(stat.TotalFlowBytes), flowBytes)
+		ratios[storeID] = ratio
+		sorted = append(sorted, ratio)
+	}
+	if len(sorted) == 0 {
+		return nil
+	}
+	sort.Float64s(sorted)
+	calibration := math.Max(1, sorted[(len(sorted)-1)/2])
+
+	var suspects map[uint64]float64
+	for storeID, ratio := range ratios {
+		if ratio/calibration > h.suspectStatsFactor {
+			if suspects == nil {
+				suspects = make(map[uint64]float64)
+			}
+			suspects[storeID] = sanitizeFloat(h.GetName(), ratio/calibration)
+			schedulerCounter.WithLabelValues(h.GetName(), "suspect_stats").Inc()
+		}
+	}
+	return suspects
+}
+
+// suspectStoresString formats the suspect stores and their calibrated ratios,
+// e.g. "store3=12.50,store7=2.10".
+func (h *balanceHotRegionsScheduler) suspectStoresString() string {
+	ids := make([]uint64, 0, len(h.suspectStores))
+	for id := range h.suspectStores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, fmt.Sprintf("store%d=%.2f", id, h.suspectStores[id]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// calcWriteAmplification collects the estimated write amplification of the
+// stores. It returns nil if the dimension is disabled or the cluster cannot
+// estimate it.
+func (h *balanceHotRegionsScheduler) calcWriteAmplification(cluster schedule.Cluster) map[uint64]float64 {
+	if !h.writeAmplification {
+		return nil
+	}
+	provider, ok := cluster.(schedule.WriteAmplificationProvider)
+	if !ok {
+		return nil
+	}
+	writeAmplification := make(map[uint64]float64)
+	for _, store := range cluster.GetStores() {
+		amplification, ok := provider.GetStoreWriteAmplification(store.GetId())
+		if amplification = sanitizeFloat(h.GetName(), amplification); ok && amplification > 0 {
+			writeAmplification[store.GetId()] = amplification
+		}
+	}
+	return writeAmplification
+}
+
+// applyLoadFilter returns the filter of the stores with saturated apply pools
+// for the hot write peers, nil if the check is disabled or the cluster doesn't
+// report the saturation.
+func (h *balanceHotRegionsScheduler) applyLoadFilter(cluster schedule.Cluster) schedule.Filter {
+	if h.balanceType != hotWriteRegionBalance || h.applyLoadThreshold <= 0 {
+		return nil
+	}
+	provider, ok := cluster.(schedule.ApplyLoadProvider)
+	if !ok {
+		return nil
+	}
+	return schedule.NewApplyLoadFilter(provider, h.applyLoadThreshold)
+}
+
+// applyLoad returns the saturation of the apply pool of the store for the hot
+// write balancing, the second return value is false if it is unknown.
+func (h *balanceHotRegionsScheduler) applyLoad(cluster schedule.Cluster, storeID uint64) (float64, bool) {
+	provider, ok := cluster.(schedule.ApplyLoadProvider)
+	if !ok || storeID == 0 || h.balanceType != hotWriteRegionBalance {
+		return 0, false
+	}
+	load, ok := provider.GetStoreApplyLoad(storeID)
+	return sanitizeFloat(h.GetName(), load), ok
+}
+
 type Feature struct {
 	// 	[{"feature_type":"Category", "name":"hotRegionsCount1", "value":"true"},{"feature_type":"Category", "name":"minRegionsCount1", "value":"true"}]
 	FeatureType string `json:"feature_type"`
@@ -452,111 +2206,348 @@ type Feature struct {
 	Value       string `json:"value"`
 }
 
+// DestStoreSelection is the destination store chosen by selectDestStore.
+type DestStoreSelection struct {
+	// StoreID is the chosen store, 0 if no store is chosen.
+	StoreID uint64
+	// Features describe why the store is chosen, they are empty if no store
+	// is chosen.
+	Features []Feature
+	// Strategy is the reason why the store is chosen, such as "fewer-regions".
+	Strategy string
+	// Score is the flow of the source store the chosen store is below, as
+	// a fraction of the source flow in [0, 1]. The higher, the colder.
+	Score float64
+	// Ranked is the candidates ranked by preference.
+	Ranked []uint64
+}
+
 // selectDestStore selects a target store to hold the region of the source region.
-// We choose a target store based on the hot region number and flow bytes of this store.
-func (h *balanceHotRegionsScheduler) selectDestStore(candidateStoreIDs []uint64, regionFlowBytes uint64, srcStoreID uint64, storesStat core.StoreHotRegionsStat) (uint64, []Feature) {
+// We choose a target store based on the hot region number and flow of this store in the dimension.
+// If relaxed is true, the improvement margin is relaxed: any store with fewer
+// hot regions or less flow is acceptable. In either case, the chosen store
+// doesn't become hotter than the source store after the move. The flows are
+// normalized by the capacities of the stores unless "capacity-weight" is
+// disabled, so a larger store is preferred with the same flow.
+func (h *balanceHotRegionsScheduler) selectDestStore(candidateStoreIDs []uint64, regionFlow uint64, srcStoreID uint64, storesStat core.StoreHotRegionsStat, relaxed bool) DestStoreSelection {
 	sr := storesStat[srcStoreID]
-	srcFlowBytes := sr.TotalFlowBytes
+	srcFlow := h.weighFlow(srcStoreID, h.storeFlow(sr))
 	srcHotRegionsCount := sr.RegionsStat.Len()
 
+	regionsCountMargin, flowMargin, scheduleFactor := h.minHotRegionCount-1, 2*regionFlow, h.scheduleFactor
+	if relaxed {
+		regionsCountMargin, flowMargin, scheduleFactor = 0, regionFlow, 1
+	}
+
 	var (
 		destStoreID     uint64
-		minFlowBytes    uint64 = math.MaxUint64
+		minFlow         uint64 = math.MaxUint64
 		minRegionsCount        = int(math.MaxInt32)
+		// minEmptyLoad is the weighted flow of the region on the chosen
+		// store without hot load.
+		minEmptyLoad uint64 = math.MaxUint64
 	)
-	var strategies []Feature
+	// canAbsorb reports whether the store doesn't become hotter than the
+	// source store after the move. The flows are normalized by the
+	// capacities, so is the margin by the capacity of the store.
+	canAbsorb := func(storeID, flow uint64) bool {
+		return flow+h.weighFlow(storeID, flowMargin) <= srcFlow
+	}
+	// store id -> the reason why the candidate is chosen, a later candidate
+	// may supersede the former ones.
+	matched := make(map[uint64]string)
 	for _, storeID := range candidateStoreIDs {
 		if s, ok := storesStat[storeID]; ok {
-			if srcHotRegionsCount-s.RegionsStat.Len() > 1 && minRegionsCount > s.RegionsStat.Len() {
+			flow := h.weighFlow(storeID, h.storeFlow(s))
+			if srcHotRegionsCount-s.RegionsStat.Len() > regionsCountMargin && minRegionsCount > s.RegionsStat.Len() &&
+				canAbsorb(storeID, flow) {
+				matched[storeID] = candidateFewerRegions
+				h.traceCandidate(storeID, candidateFewerRegions)
 				destStoreID = storeID
-				minFlowBytes = s.TotalFlowBytes
+				minFlow = flow
 				minRegionsCount = s.RegionsStat.Len()
-				str1 := fmt.Sprintf("hotRegionsCount%d", storeID)
-				str2 := fmt.Sprintf("minRegionsCount%d", storeID)
-				strategy := Feature{}
-				strategy.FeatureType = "Category"
-				strategy.Name = str1
-				strategy.Value = "true"
-				strategies = append(strategies, strategy)
-				strategy1 := Feature{}
-				strategy1.FeatureType = "Category"
-				strategy1.Name = str2
-				strategy1.Value = "true"
-				strategies = append(strategies, strategy1)
 				continue
 			}
-			if minRegionsCount == s.RegionsStat.Len() && minFlowBytes > s.TotalFlowBytes &&
-				uint64(float64(srcFlowBytes)*hotRegionScheduleFactor) > s.TotalFlowBytes+2*regionFlowBytes {
-				minFlowBytes = s.TotalFlowBytes
+			if minRegionsCount == s.RegionsStat.Len() && minFlow > flow &&
+				uint64(float64(srcFlow)*scheduleFactor) > flow+h.weighFlow(storeID, flowMargin) {
+				matched[storeID] = candidateLessFlow
+				h.traceCandidate(storeID, candidateLessFlow)
+				minFlow = flow
 				destStoreID = storeID
-				str1 := fmt.Sprintf("minFlowBytes%d", storeID)
-				str2 := fmt.Sprintf("srcFlowBytes%d", storeID)
-				strategy2 := Feature{}
-				strategy2.FeatureType = "Category"
-				strategy2.Name = str1
-				strategy2.Value = "true"
-				strategies = append(strategies, strategy2)
-				strategy3 := Feature{}
-				strategy3.FeatureType = "Category"
-				strategy3.Name = str2
-				strategy3.Value = "true"
-				strategies = append(strategies, strategy3)
+				continue
+			}
+			h.traceCandidate(storeID, candidateInsufficientMargin)
+		} else if canAbsorb(storeID, 0) {
+			// The store has no hot load, which is the best target if it can
+			// absorb the region. Among such stores, the one the region loads
+			// the least by capacity is chosen wherever it is listed, so a
+			// small store is not overloaded while a larger one is idle.
+			matched[storeID] = candidateNoHotRegion
+			h.traceCandidate(storeID, candidateNoHotRegion)
+			if load := h.weighFlow(storeID, regionFlow); load < minEmptyLoad {
+				destStoreID, minEmptyLoad = storeID, load
+				minFlow, minRegionsCount = 0, 0
 			}
 		} else {
-			destStoreID = storeID
-			return destStoreID, strategies
+			h.traceCandidate(storeID, candidateInsufficientMargin)
+		}
+	}
+	selection := DestStoreSelection{Ranked: rankDestStores(candidateStoreIDs, destStoreID, storesStat, h.storeFlow)}
+	if destStoreID == 0 {
+		return selection
+	}
+	selection.StoreID, selection.Strategy = destStoreID, matched[destStoreID]
+	h.observeSelection(hotSelectionDest, destStoreID, storesStat[destStoreID])
+	selection.Score = safeDiv(float64(srcFlow-minFlow), float64(srcFlow))
+	if h.effectiveSchema() == featureSchemaV2 {
+		selection.Features = h.fixedFeatures(candidateStoreIDs, matched, srcStoreID)
+	} else {
+		selection.Features = destStoreFeatures(destStoreID, selection.Strategy, srcStoreID)
+	}
+	selection.Features = append(selection.Features, h.schemaFeature()...)
+	return selection
+}
+
+const (
+	hotSelectionSource = "source"
+	hotSelectionDest   = "dest"
+)
+
+// The stages of a dispatch whose latencies are observed.
+const (
+	hotStageCalcScore       = "calc_score"
+	hotStageBalanceByPeer   = "balance_by_peer"
+	hotStageBalanceByLeader = "balance_by_leader"
+)
+
+// observeStage records the duration of the stage of the current dispatch
+// since start, labeled by the balance type.
+func (h *balanceHotRegionsScheduler) observeStage(stage string, start time.Time) {
+	// Never observe the hypothetical decisions.
+	if h.hypothetical() {
+		return
+	}
+	hotScheduleStageLatency.WithLabelValues(h.balanceType.String(), stage).Observe(time.Since(start).Seconds())
+}
+
+// observeSelection exports the hot statistics of the store selected as the
+// source or the destination, labeled by the store and the balance type, so
+// the cardinality is bounded by the number of stores. Both dimensions are
+// exported whichever is balanced on, and the region count is the movable
+// regions the selection compares.
+func (h *balanceHotRegionsScheduler) observeSelection(role string, storeID uint64, stat *core.HotRegionsStat) {
+	// Never export the hypothetical decisions.
+	if storeID == 0 || h.hypothetical() {
+		return
+	}
+	var (
+		flowBytes, flowKeys uint64
+		count               int
+	)
+	// The destination has no statistics if it has no hot region.
+	if stat != nil {
+		flowBytes, flowKeys, count = stat.TotalFlowBytes, stat.TotalFlowKeys, stat.RegionsStat.Len()
+	}
+	store, typ := strconv.FormatUint(storeID, 10), h.balanceType.String()
+	hotSelectionGauge.WithLabelValues(store, typ, role+"_flow_bytes").Set(float64(flowBytes))
+	hotSelectionGauge.WithLabelValues(store, typ, role+"_flow_keys").Set(float64(flowKeys))
+	hotSelectionGauge.WithLabelValues(store, typ, role+"_region_count").Set(float64(count))
+}
+
+// destStoreFeatures describes why the destination store is chosen, followed
+// by the source store.
+func destStoreFeatures(destStoreID uint64, reason string, srcStoreID uint64) []Feature {
+	var features []Feature
+	switch reason {
+	case candidateFewerRegions:
+		features = append(features,
+			hotFeatures.Build(featureHotRegionsCount, destStoreID, "true"),
+			hotFeatures.Build(featureMinRegionsCount, destStoreID, "true"),
+		)
+	case candidateLessFlow:
+		features = append(features,
+			hotFeatures.Build(featureMinFlowBytes, destStoreID, "true"),
+			hotFeatures.Build(featureSrcFlowBytes, destStoreID, "true"),
+		)
+	}
+	return append(features, hotFeatures.Build(featureSrcRegion, 0, strconv.FormatUint(srcStoreID, 10)))
+}
+
+// rankDestStores ranks the candidates in the preference order of the
+// heuristics: the chosen store first, then the others by hot region count
+// and flow, the stores without hot region are the most preferred.
+func rankDestStores(candidateStoreIDs []uint64, destStoreID uint64, storesStat core.StoreHotRegionsStat, storeFlow func(*core.HotRegionsStat) uint64) []uint64 {
+	load := func(storeID uint64) (int, uint64) {
+		if s, ok := storesStat[storeID]; ok {
+			return s.RegionsStat.Len(), storeFlow(s)
+		}
+		return 0, 0
+	}
+	ranked := append([]uint64(nil), candidateStoreIDs...)
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if (a == destStoreID) != (b == destStoreID) {
+			return a == destStoreID
+		}
+		countA, flowA := load(a)
+		countB, flowB := load(b)
+		if countA != countB {
+			return countA < countB
+		}
+		if flowA != flowB {
+			return flowA < flowB
+		}
+		return a < b
+	})
+	return ranked
+}
+
+// modelSuggestionRank returns the 1-based rank of the store suggested by the
+// model in the ranked candidates, or 0 if it is not a candidate.
+func modelSuggestionRank(ranked []uint64, suggestedStoreID uint64) int {
+	for i, id := range ranked {
+		if id == suggestedStoreID {
+			return i + 1
 		}
 	}
-	strategy := Feature{}
-	strategy.FeatureType = "Category"
-	strategy.Name = "srcRegion"
-	strategy.Value = fmt.Sprintf("%d", srcStoreID)
-	strategies = append(strategies, strategy)
-	return destStoreID, strategies
+	return 0
 }
 
-func (h *balanceHotRegionsScheduler) adjustBalanceLimit(storeID uint64, storesStat core.StoreHotRegionsStat) {
+// adjustBalanceLimit derives the limit from the surplus of the source store,
+// which is the smaller one of the hot region count above the average and the
+// flow above the average in the unit of the average hot region flow. So that
+// a store with many barely hot regions does not inflate the limit. The limit
+// is clamped to maxLimit if it is set. Only the limit of the balance type is
+// adjusted.
+func (h *balanceHotRegionsScheduler) adjustBalanceLimit(typ BalanceType, storeID uint64, storesStat core.StoreHotRegionsStat) {
+	if h.preview {
+		return
+	}
 	srcStoreStatistics := storesStat[storeID]
 
-	var hotRegionTotalCount float64
+	var hotRegionTotalCount, totalFlowBytes, totalRegionsCount float64
 	for _, m := range storesStat {
 		hotRegionTotalCount += float64(m.RegionsStat.Len())
+		totalFlowBytes += float64(m.TotalFlowBytes)
+		totalRegionsCount += float64(m.RegionsCount)
+	}
+
+	avgRegionCount := safeDiv(hotRegionTotalCount, float64(len(storesStat)))
+	countSurplus := math.Max(float64(srcStoreStatistics.RegionsStat.Len())-avgRegionCount, 0)
+	flowSurplus := countSurplus
+	if totalFlowBytes > 0 {
+		avgFlowBytes := safeDiv(totalFlowBytes, float64(len(storesStat)))
+		avgRegionFlowBytes := safeDiv(totalFlowBytes, totalRegionsCount)
+		flowSurplus = safeDiv(math.Max(float64(srcStoreStatistics.TotalFlowBytes)-avgFlowBytes, 0), avgRegionFlowBytes)
 	}
+	// Multiplied by limitFactor to avoid transfer back and forth
+	limit := uint64(math.Min(countSurplus, flowSurplus) * h.limitFactor)
+	if h.maxLimit > 0 && limit > h.maxLimit {
+		limit = h.maxLimit
+	}
+	newLimit := maxUint64(h.baseLimit, limit)
+	h.limitInputs = fmt.Sprintf("count-surplus=%.2f,flow-surplus=%.2f,max=%d,limit=%d", countSurplus, flowSurplus, h.maxLimit, newLimit)
+	log.Debugf("[%s] adjust %s of store %d: %s", h.GetName(), limitName(typ), storeID, h.limitInputs)
+	if err := h.setTunable(limitName(typ), newLimit, core.HotChangeSourceInternal, fmt.Sprintf("surplus of store %d: %s", storeID, h.limitInputs)); err != nil {
+		log.Errorf("[%s] failed to adjust the limit: %v", h.GetName(), err)
+	}
+}
 
-	avgRegionCount := hotRegionTotalCount / float64(len(storesStat))
-	// Multiplied by hotRegionLimitFactor to avoid transfer back and forth
-	limit := uint64((float64(srcStoreStatistics.RegionsStat.Len()) - avgRegionCount) * hotRegionLimitFactor)
-	h.limit = maxUint64(1, limit)
+// GetLimit returns the current limit of the running hot region operators of
+// the balance type.
+func (h *balanceHotRegionsScheduler) GetLimit(typ BalanceType) uint64 {
+	h.RLock()
+	defer h.RUnlock()
+	return *h.limitOf(typ)
 }
 
-func (h *balanceHotRegionsScheduler) GetHotReadStatus() *core.StoreHotRegionInfos {
+// NeedExpeditedTick implements schedule.ExpeditedScheduler, it returns true
+// if more hot regions of the source store of the last operator remain to be
+// moved.
+func (h *balanceHotRegionsScheduler) NeedExpeditedTick() bool {
 	h.RLock()
 	defer h.RUnlock()
-	asLeader := make(core.StoreHotRegionsStat, len(h.stats.readStatAsLeader))
-	for id, stat := range h.stats.readStatAsLeader {
-		clone := *stat
-		asLeader[id] = &clone
-	}
-	return &core.StoreHotRegionInfos{
-		AsLeader: asLeader,
-	}
+	return h.expedite
+}
+
+func (h *balanceHotRegionsScheduler) GetHotReadStatus() *core.StoreHotRegionInfos {
+	read, _ := h.GetHotStatus()
+	return read
 }
 
 func (h *balanceHotRegionsScheduler) GetHotWriteStatus() *core.StoreHotRegionInfos {
+	_, write := h.GetHotStatus()
+	return write
+}
+
+// GetHotStatus returns the copies of the read and write statistics taken
+// under the lock at once, so they are consistent with each other.
+func (h *balanceHotRegionsScheduler) GetHotStatus() (read, write *core.StoreHotRegionInfos) {
 	h.RLock()
 	defer h.RUnlock()
-	asLeader := make(core.StoreHotRegionsStat, len(h.stats.writeStatAsLeader))
-	asPeer := make(core.StoreHotRegionsStat, len(h.stats.writeStatAsPeer))
-	for id, stat := range h.stats.writeStatAsLeader {
-		clone := *stat
-		asLeader[id] = &clone
+	read = &core.StoreHotRegionInfos{
+		AsLeader:         snapshotStoreHotRegionsStat(h.stats.readStatAsLeader),
+		LeaderLabel:      h.leaderLabelStatus(),
+		Limit:            h.readLimit,
+		Advisories:       h.advisoriesStatus(),
+		Exclusion:        h.exclusion.status(),
+		PendingFlowBytes: h.pendingFlowBytes(hotReadRegionBalance),
+		ClockSkew:        h.clockSkewStatus(),
+		LastUpdateTime:   h.stats.lastUpdate,
+		DisabledFeatures: h.disabledFeaturesStatus(),
+	}
+	write = &core.StoreHotRegionInfos{
+		AsLeader:         snapshotStoreHotRegionsStat(h.stats.writeStatAsLeader),
+		AsPeer:           snapshotStoreHotRegionsStat(h.stats.writeStatAsPeer),
+		LeaderLabel:      h.leaderLabelStatus(),
+		Limit:            h.writeLimit,
+		Exclusion:        h.exclusion.status(),
+		PendingFlowBytes: h.pendingFlowBytes(hotWriteRegionBalance),
+		ClockSkew:        h.clockSkewStatus(),
+		LastUpdateTime:   h.stats.lastUpdate,
+		DisabledFeatures: h.disabledFeaturesStatus(),
+	}
+	return read, write
+}
+
+// snapshotStoreHotRegionsStat deep copies the statistics, nothing is shared
+// with the scheduler, so the copy can be read without holding the lock. The
+// StoreID of each copied region statistic is set to the store it is listed
+// under, so the invariant always holds for the consumers of the copy.
+func snapshotStoreHotRegionsStat(stats core.StoreHotRegionsStat) core.StoreHotRegionsStat {
+	snapshot := make(core.StoreHotRegionsStat, len(stats))
+	for storeID, stat := range stats {
+		regionsStat := make(core.RegionsStat, len(stat.RegionsStat))
+		copy(regionsStat, stat.RegionsStat)
+		for i := range regionsStat {
+			regionsStat[i].StoreID = storeID
+			if regionsStat[i].Stats != nil {
+				regionsStat[i].Stats = regionsStat[i].Stats.Clone()
+			}
+		}
+		snapshot[storeID] = &core.HotRegionsStat{
+			TotalFlowBytes: stat.TotalFlowBytes,
+			TotalFlowKeys:  stat.TotalFlowKeys,
+			RegionsCount:   stat.RegionsCount,
+			RegionsStat:    regionsStat,
+		}
 	}
-	for id, stat := range h.stats.writeStatAsPeer {
-		clone := *stat
-		asPeer[id] = &clone
+	return snapshot
+}
+
+// disabledFeaturesStatus returns a copy of the features disabled by the
+// cluster version, nil if there is none.
+func (h *balanceHotRegionsScheduler) disabledFeaturesStatus() []string {
+	if len(h.disabledByVersion) == 0 {
+		return nil
 	}
-	return &core.StoreHotRegionInfos{
-		AsLeader: asLeader,
-		AsPeer:   asPeer,
+	return append([]string(nil), h.disabledByVersion...)
+}
+
+func (h *balanceHotRegionsScheduler) leaderLabelStatus() *core.LeaderLabelConstraint {
+	if h.leaderLabel.Key == "" {
+		return nil
 	}
+	constraint := h.leaderLabel
+	return &constraint
 }
@@ -0,0 +1,88 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sort"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+	log "github.com/sirupsen/logrus"
+)
+
+// adviceFollowerReadSufficient means the follower reads are estimated to
+// cool the hot read region down, so it is not moved.
+const adviceFollowerReadSufficient = "follower-read-sufficient"
+
+// adviseFollowerRead estimates the read flow of the region leader after
+// followerReadFraction of the reads are served by the followers. If it is
+// below the hot threshold, the advisory is recorded and true is returned, the
+// region should not be moved then.
+func (h *balanceHotRegionsScheduler) adviseFollowerRead(cluster schedule.Cluster, region *core.RegionInfo, storesStat core.StoreHotRegionsStat) bool {
+	if h.followerReadFraction <= 0 || h.disabledByVersionGate(hotFeatureFollowerRead) {
+		return false
+	}
+	storeID := region.GetLeader().GetStoreId()
+	flowBytes := hotRegionFlowBytes(region.GetID(), storesStat, storeID)
+	estimated := uint64(float64(flowBytes) * (1 - h.followerReadFraction))
+	threshold := schedule.ReadHotRegionThreshold(cluster.GetStores())
+	if estimated >= threshold {
+		return false
+	}
+	h.advisories[region.GetID()] = core.HotRegionAdvisory{
+		Time:               h.now(),
+		RegionID:           region.GetID(),
+		StoreID:            storeID,
+		Advice:             adviceFollowerReadSufficient,
+		FlowBytes:          flowBytes,
+		EstimatedFlowBytes: estimated,
+		HotThreshold:       threshold,
+	}
+	schedulerCounter.WithLabelValues(h.GetName(), "follower_read_sufficient").Inc()
+	log.Infof("[%s] follower reads are estimated to cool hot region %d on store %d down from %d to %d bytes/s, below the hot threshold %d, skip it",
+		h.GetName(), region.GetID(), storeID, flowBytes, estimated, threshold)
+	return true
+}
+
+// pruneAdvisories drops the advisories of the regions which are no longer
+// hot read leaders.
+func (h *balanceHotRegionsScheduler) pruneAdvisories(storesStat core.StoreHotRegionsStat) {
+	if len(h.advisories) == 0 {
+		return
+	}
+	hot := make(map[uint64]struct{})
+	for _, stat := range storesStat {
+		for _, rs := range stat.RegionsStat {
+			hot[rs.RegionID] = struct{}{}
+		}
+	}
+	for regionID := range h.advisories {
+		if _, ok := hot[regionID]; !ok {
+			delete(h.advisories, regionID)
+		}
+	}
+}
+
+// advisoriesStatus returns the advisories ordered by the region ID.
+func (h *balanceHotRegionsScheduler) advisoriesStatus() []core.HotRegionAdvisory {
+	if len(h.advisories) == 0 {
+		return nil
+	}
+	advisories := make([]core.HotRegionAdvisory, 0, len(h.advisories))
+	for _, advisory := range h.advisories {
+		advisories = append(advisories, advisory)
+	}
+	sort.Slice(advisories, func(i, j int) bool { return advisories[i].RegionID < advisories[j].RegionID })
+	return advisories
+}
@@ -0,0 +1,176 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultModelBreakerFailures = 5
+	defaultModelBreakerCooldown = 30 * time.Second
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// modelBreaker is the circuit breaker of the model service. It opens after
+// the given number of consecutive failures, and stays open for the cooldown.
+// Then it turns half-open and lets one probe request through, which closes
+// the breaker if it succeeds or opens it again otherwise.
+type modelBreaker struct {
+	sync.Mutex
+	// failures is the number of consecutive failures to open the breaker,
+	// 0 means never open.
+	failures int
+	cooldown time.Duration
+
+	state     breakerState
+	failed    int
+	openUntil time.Time
+	probing   bool
+	// recoveries is the number of times the breaker is closed again.
+	recoveries uint64
+	// changes records the state changes if it is not nil.
+	changes *hotChangeLog
+}
+
+func newModelBreaker(changes *hotChangeLog) *modelBreaker {
+	return &modelBreaker{
+		failures: defaultModelBreakerFailures,
+		cooldown: defaultModelBreakerCooldown,
+		changes:  changes,
+	}
+}
+
+// setState changes the state and records the change with the reason.
+func (b *modelBreaker) setState(now time.Time, state breakerState, reason string) {
+	if b.state == state {
+		return
+	}
+	if err := setTunableValue(b.changes, now, "model-breaker", &b.state, state, core.HotChangeSourceInternal, reason); err != nil {
+		log.Errorf("failed to change the model breaker state: %v", err)
+		return
+	}
+	if state == breakerClosed {
+		hotModelCircuitOpen.Set(0)
+	} else {
+		hotModelCircuitOpen.Set(1)
+	}
+}
+
+// allow checks if a request can be sent to the model service.
+func (b *modelBreaker) allow(now time.Time) bool {
+	b.Lock()
+	defer b.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if now.Before(b.openUntil) {
+			return false
+		}
+		b.setState(now, breakerHalfOpen, "cooldown elapsed, probe the model service")
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// record records the result of a request allowed by the breaker.
+func (b *modelBreaker) record(now time.Time, success bool) {
+	b.Lock()
+	defer b.Unlock()
+	if success {
+		if b.state != breakerClosed {
+			log.Info("[hot] model service circuit breaker is closed")
+			b.recoveries++
+		}
+		b.setState(now, breakerClosed, "model service request succeeded")
+		b.failed, b.probing = 0, false
+		return
+	}
+	b.failed++
+	if b.state == breakerHalfOpen || (b.failures > 0 && b.failed >= b.failures) {
+		if b.state != breakerOpen {
+			log.Warnf("[hot] model service circuit breaker is open after %d consecutive failures", b.failed)
+		}
+		b.setState(now, breakerOpen, fmt.Sprintf("%d consecutive model service failures", b.failed))
+		b.openUntil, b.probing = now.Add(b.cooldown), false
+	}
+}
+
+// cancelProbe lets another request probe the model service if the probe
+// request is not sent.
+func (b *modelBreaker) cancelProbe() {
+	b.Lock()
+	defer b.Unlock()
+	b.probing = false
+}
+
+func (b *modelBreaker) getState() breakerState {
+	b.Lock()
+	defer b.Unlock()
+	return b.state
+}
+
+func (b *modelBreaker) getRecoveries() uint64 {
+	b.Lock()
+	defer b.Unlock()
+	return b.recoveries
+}
+
+// modelBreakerStatus is the state of the circuit breaker of the model
+// service, it tells why the predictions stop.
+type modelBreakerStatus struct {
+	State string `json:"state"`
+	// Failed is the number of the consecutive failures.
+	Failed    int       `json:"failed"`
+	OpenUntil time.Time `json:"open-until"`
+}
+
+func (b *modelBreaker) status() modelBreakerStatus {
+	b.Lock()
+	defer b.Unlock()
+	return modelBreakerStatus{
+		State:     b.state.String(),
+		Failed:    b.failed,
+		OpenUntil: b.openUntil,
+	}
+}
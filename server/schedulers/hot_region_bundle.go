@@ -0,0 +1,185 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/url"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pkg/errors"
+)
+
+// hotBundleVersion is the version of the format of the support bundle.
+const hotBundleVersion = 1
+
+// redacted replaces the sensitive values in the support bundle.
+const redacted = "xxxxx"
+
+// hotSupportBundle is the internal state of the scheduler for debugging.
+type hotSupportBundle struct {
+	Version        int       `json:"version"`
+	GoVersion      string    `json:"go-version"`
+	ClusterVersion string    `json:"cluster-version"`
+	Time           time.Time `json:"time"`
+
+	Config          hotRegionSchedulerConfig `json:"config"`
+	Types           []string                 `json:"types"`
+	ModelEndpoint   string                   `json:"model-endpoint,omitempty"`
+	BaseLimit       uint64                   `json:"base-limit"`
+	Exclusion       core.HotStoreExclusion   `json:"exclusion"`
+	TargetBlacklist []uint64                 `json:"target-blacklist,omitempty"`
+
+	Read            *core.StoreHotRegionInfos `json:"read"`
+	Write           *core.StoreHotRegionInfos `json:"write"`
+	LastBalanceType string                    `json:"last-balance-type,omitempty"`
+	Summary         map[string]string         `json:"summary,omitempty"`
+	// RegionCooldown is the cooldown of the moved regions, MovedRegions are
+	// the regions in the cooldown and when they are moved.
+	RegionCooldown string                    `json:"region-cooldown"`
+	MovedRegions   map[uint64]time.Time      `json:"moved-regions,omitempty"`
+	Pendings       []hotBundlePending        `json:"pendings,omitempty"`
+	Breaker        modelBreakerStatus        `json:"breaker"`
+	History        []core.HotRegionDecision  `json:"history"`
+	Changes        []core.HotSchedulerChange `json:"changes"`
+}
+
+// hotBundlePending is the pending influence of an operator in flight.
+type hotBundlePending struct {
+	RegionID    uint64 `json:"region-id"`
+	BalanceType string `json:"balance-type"`
+	SrcStoreID  uint64 `json:"src-store-id"`
+	DestStoreID uint64 `json:"dest-store-id"`
+	FlowBytes   uint64 `json:"flow-bytes"`
+	FlowKeys    uint64 `json:"flow-keys"`
+}
+
+// SupportBundle writes the internal state of the scheduler, the config and
+// the versions as a gzipped JSON document, which is attached to the bug
+// reports. The credentials in the URLs are redacted.
+func (h *balanceHotRegionsScheduler) SupportBundle(w io.Writer) error {
+	bundle := h.supportBundle()
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(bundle); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(gz.Close())
+}
+
+// DebugDump writes the same state as SupportBundle as an indented JSON
+// document, which is read by the operators directly, e.g. to audit the
+// changes of the scheduler.
+func (h *balanceHotRegionsScheduler) DebugDump(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return errors.WithStack(encoder.Encode(h.supportBundle()))
+}
+
+func (h *balanceHotRegionsScheduler) supportBundle() *hotSupportBundle {
+	read, write := h.GetHotStatus()
+	bundle := &hotSupportBundle{
+		Version:   hotBundleVersion,
+		GoVersion: runtime.Version(),
+		Config:    h.config(),
+		Read:      read,
+		Write:     write,
+		History:   h.GetHotRegionHistory(0),
+		Changes:   h.changes.latest(0),
+		Summary:   h.GetSummary(),
+		Exclusion: h.GetStoreExclusion(),
+	}
+	if typ, ok := h.LastBalanceType(); ok {
+		bundle.LastBalanceType = typ.String()
+	}
+	bundle.Config.ModelURL = redactURL(bundle.Config.ModelURL)
+	for i, change := range bundle.Changes {
+		if change.Name == "model-url" {
+			bundle.Changes[i].Old, bundle.Changes[i].New = redactURL(change.Old), redactURL(change.New)
+		}
+	}
+
+	h.RLock()
+	bundle.Time = h.now()
+	bundle.ClusterVersion = h.clusterVersion.String()
+	if h.modelEndpoint != nil {
+		bundle.ModelEndpoint = redactURL(*h.modelEndpoint)
+	}
+	bundle.BaseLimit = h.baseLimit
+	bundle.RegionCooldown = h.regionCooldown.String()
+	for _, typ := range h.types {
+		bundle.Types = append(bundle.Types, typ.String())
+	}
+	for id := range h.targetBlacklist {
+		bundle.TargetBlacklist = append(bundle.TargetBlacklist, id)
+	}
+	if len(h.movedRegions) != 0 {
+		bundle.MovedRegions = make(map[uint64]time.Time, len(h.movedRegions))
+		for id, t := range h.movedRegions {
+			bundle.MovedRegions[id] = t
+		}
+	}
+	for id, p := range h.pendings {
+		bundle.Pendings = append(bundle.Pendings, hotBundlePending{
+			RegionID:    id,
+			BalanceType: p.typ.String(),
+			SrcStoreID:  p.srcStoreID,
+			DestStoreID: p.destStoreID,
+			FlowBytes:   p.flowBytes,
+			FlowKeys:    p.flowKeys,
+		})
+	}
+	h.RUnlock()
+	sort.Slice(bundle.TargetBlacklist, func(i, j int) bool { return bundle.TargetBlacklist[i] < bundle.TargetBlacklist[j] })
+	sort.Slice(bundle.Pendings, func(i, j int) bool { return bundle.Pendings[i].RegionID < bundle.Pendings[j].RegionID })
+	bundle.Breaker = h.breaker.status()
+	return bundle
+}
+
+// redactURL hides the password and the credentials in the query of the URL.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// The URL is unknown, nothing of it is kept.
+		return redacted
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+	}
+	query := u.Query()
+	for key := range query {
+		if isSensitiveQueryKey(key) {
+			query.Set(key, redacted)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func isSensitiveQueryKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range []string{"token", "secret", "password", "key", "auth"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"github.com/pingcap/pd/server/core"
+)
+
+// storeCapacityWeights returns the capacity of each store relative to the
+// average capacity of the stores. It returns nil if the capacities are all
+// the same, so that the hot load is compared as is. The stores without the
+// capacity reported are weighted 1.
+func storeCapacityWeights(stores []*core.StoreInfo) map[uint64]float64 {
+	var (
+		total, first uint64
+		count        int
+		uniform      = true
+	)
+	for _, store := range stores {
+		capacity := store.Stats.GetCapacity()
+		if capacity == 0 {
+			continue
+		}
+		if count == 0 {
+			first = capacity
+		}
+		uniform = uniform && capacity == first
+		total += capacity
+		count++
+	}
+	if count == 0 || uniform {
+		return nil
+	}
+	average := float64(total) / float64(count)
+	weights := make(map[uint64]float64, count)
+	for _, store := range stores {
+		if capacity := store.Stats.GetCapacity(); capacity != 0 {
+			weights[store.GetId()] = float64(capacity) / average
+		}
+	}
+	return weights
+}
+
+// weighFlow normalizes the flow of the store by its capacity, so that a store
+// twice as large as the average holds the same load with twice the flow.
+func (h *balanceHotRegionsScheduler) weighFlow(storeID, flow uint64) uint64 {
+	weight, ok := h.capacityWeights[storeID]
+	if !ok || weight <= 0 {
+		return flow
+	}
+	return uint64(float64(flow) / weight)
+}
@@ -0,0 +1,168 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultHotChangeLogCapacity is the number of the latest changes kept by
+// the hot region scheduler.
+const defaultHotChangeLogCapacity = 256
+
+// hotChangeLog is a ring buffer of the latest changes. It has its own lock
+// since the changes are also made by the model workers, e.g. the circuit
+// breaker state.
+type hotChangeLog struct {
+	sync.Mutex
+	name    string
+	changes []core.HotSchedulerChange
+	next    int
+	full    bool
+}
+
+func newHotChangeLog(name string, capacity int) *hotChangeLog {
+	return &hotChangeLog{name: name, changes: make([]core.HotSchedulerChange, capacity)}
+}
+
+// add records the change and logs it. It is only called when the value is
+// changed, so the log is not flooded by the unchanged adjustments.
+func (l *hotChangeLog) add(now time.Time, name string, old, new interface{}, source, reason string) {
+	change := core.HotSchedulerChange{
+		Time:   now,
+		Name:   name,
+		Old:    tunableString(old),
+		New:    tunableString(new),
+		Source: source,
+		Reason: reason,
+	}
+	log.Infof("[%s] %s is changed from %s to %s by %s: %s", l.name, name, change.Old, change.New, source, reason)
+	l.Lock()
+	defer l.Unlock()
+	l.changes[l.next] = change
+	l.next++
+	if l.next == len(l.changes) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// latest returns at most limit changes from the latest to the oldest, all
+// the changes are returned if limit is not positive.
+func (l *hotChangeLog) latest(limit int) []core.HotSchedulerChange {
+	l.Lock()
+	defer l.Unlock()
+	n := l.next
+	if l.full {
+		n = len(l.changes)
+	}
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	changes := make([]core.HotSchedulerChange, 0, n)
+	for i := 1; i <= n; i++ {
+		changes = append(changes, l.changes[(l.next-i+len(l.changes))%len(l.changes)])
+	}
+	return changes
+}
+
+// tunables returns the state changed by the scheduler itself or by the API,
+// keyed by the name in the change log. The fields are tagged with
+// `tunable:"name"`, and they must only be changed by setTunable after the
+// scheduler is created. The state of the circuit breaker is changed by
+// setTunableValue under its own lock.
+func (h *balanceHotRegionsScheduler) tunables() map[string]interface{} {
+	return map[string]interface{}{
+		"read-limit":                     &h.readLimit,
+		"write-limit":                    &h.writeLimit,
+		"limit":                          &h.baseLimit,
+		"max-limit":                      &h.maxLimit,
+		"model-url":                      &h.modelURL,
+		"model-endpoint":                 &h.modelEndpoint,
+		"model-disabled":                 &h.modelDisabled,
+		"keys-weight":                    &h.keysWeight,
+		"retry-limit":                    &h.retryLimit,
+		"key-range":                      &h.keyRange,
+		"schedule-factor":                &h.scheduleFactor,
+		"limit-factor":                   &h.limitFactor,
+		"model-mode":                     &h.modelMode,
+		"model-threshold":                &h.modelThreshold,
+		"dry-run":                        &h.dryRun,
+		"types":                          &h.types,
+		"exclusion":                      &h.exclusion,
+		"target-blacklist":               &h.targetBlacklist,
+		"min-region-flow-bytes":          &h.minRegionFlowBytes,
+		"min-region-flow-bytes-override": &h.minRegionFlowBytesOverride,
+	}
+}
+
+// setTunable changes the tunable state and records the change with the
+// source and the reason if the value is changed.
+func (h *balanceHotRegionsScheduler) setTunable(name string, value interface{}, source, reason string) error {
+	p, ok := h.tunables()[name]
+	if !ok {
+		return errors.Errorf("unknown tunable %q", name)
+	}
+	return setTunableValue(h.changes, h.now(), name, p, value, source, reason)
+}
+
+// tunableString formats the tunable value in the change log, the pointers
+// are dereferenced and nil is "none".
+func tunableString(value interface{}) string {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "none"
+		}
+		return fmt.Sprint(v.Elem().Interface())
+	}
+	return fmt.Sprint(value)
+}
+
+// setTunableValue sets the value pointed by p, and records the change with
+// the source and the reason in changes if the value is changed and changes
+// is not nil.
+func setTunableValue(changes *hotChangeLog, now time.Time, name string, p, value interface{}, source, reason string) error {
+	dest := reflect.ValueOf(p)
+	if dest.Kind() != reflect.Ptr || dest.IsNil() {
+		return errors.Errorf("invalid tunable %q of %T", name, p)
+	}
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || v.Type() != dest.Elem().Type() {
+		return errors.Errorf("invalid value %v of tunable %q, it must be %s", value, name, dest.Elem().Type())
+	}
+	old := dest.Elem().Interface()
+	if reflect.DeepEqual(old, value) {
+		return nil
+	}
+	dest.Elem().Set(v)
+	if changes != nil {
+		changes.add(now, name, old, value, source, reason)
+	}
+	return nil
+}
+
+// GetHotSchedulerChanges returns at most limit latest changes of
+// the scheduler, from the latest to the oldest. All the kept changes are
+// returned if limit is not positive.
+func (h *balanceHotRegionsScheduler) GetHotSchedulerChanges(limit int) []core.HotSchedulerChange {
+	return h.changes.latest(limit)
+}
@@ -0,0 +1,110 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pingcap/pd/server/core"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// hotStatsClockTolerance is how far the update time of a hot region stat
+	// may be ahead of PD before it is treated as future-dated.
+	hotStatsClockTolerance = 5 * time.Second
+	// hotStatsClockSkewRatio is the ratio of the future-dated stats of a
+	// store in a dispatch above which the clock of the store is warned.
+	hotStatsClockSkewRatio = 0.5
+)
+
+// storeClockSkew records the future-dated hot region stats of a store.
+type storeClockSkew struct {
+	// futureCount is the number of the future-dated stats since the
+	// scheduler starts.
+	futureCount uint64
+	// ratio is the ratio of the future-dated stats in the last dispatch.
+	ratio float64
+}
+
+// clampFutureStats returns the stats with the update time ahead of now beyond
+// hotStatsClockTolerance clamped to now, so that the calculations never see
+// a time in the future. The stats are shared with the hot cache, only the
+// clamped ones are copied. It also returns the number of the future-dated
+// stats and all the stats per store.
+func clampFutureStats(items []*core.RegionStat, now time.Time) (clamped []*core.RegionStat, future, total map[uint64]int) {
+	clamped = items
+	copied := false
+	future, total = make(map[uint64]int), make(map[uint64]int)
+	for i, r := range items {
+		total[r.StoreID]++
+		if !r.LastUpdateTime.After(now.Add(hotStatsClockTolerance)) {
+			continue
+		}
+		future[r.StoreID]++
+		if !copied {
+			clamped, copied = append([]*core.RegionStat(nil), items...), true
+		}
+		clone := *r
+		clone.LastUpdateTime = now
+		clamped[i] = &clone
+	}
+	return clamped, future, total
+}
+
+// clampStats clamps the future-dated stats entering the scheduler and records
+// the clock skew of the stores.
+func (h *balanceHotRegionsScheduler) clampStats(items []*core.RegionStat) []*core.RegionStat {
+	items, future, total := clampFutureStats(items, h.now())
+	for storeID, count := range total {
+		skew, ok := h.clockSkew[storeID]
+		if !ok {
+			if future[storeID] == 0 {
+				continue
+			}
+			skew = &storeClockSkew{}
+			h.clockSkew[storeID] = skew
+		}
+		skew.futureCount += uint64(future[storeID])
+		skew.ratio = safeDiv(float64(future[storeID]), float64(count))
+		if future[storeID] == 0 {
+			continue
+		}
+		schedulerCounter.WithLabelValues(h.GetName(), "future_stats").Add(float64(future[storeID]))
+		if skew.ratio >= hotStatsClockSkewRatio {
+			log.Warnf("[%s] %d of %d hot region stats of store %d are ahead of PD, the clock of the store may be skewed",
+				h.GetName(), future[storeID], count, storeID)
+		}
+	}
+	return items
+}
+
+// clockSkewStatus returns the stores whose clocks are likely skewed, nil if
+// there is none.
+func (h *balanceHotRegionsScheduler) clockSkewStatus() []core.HotStoreClockSkew {
+	var status []core.HotStoreClockSkew
+	for storeID, skew := range h.clockSkew {
+		if skew.ratio < hotStatsClockSkewRatio {
+			continue
+		}
+		status = append(status, core.HotStoreClockSkew{
+			StoreID:          storeID,
+			FutureStatsCount: skew.futureCount,
+			FutureStatsRatio: skew.ratio,
+		})
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].StoreID < status[j].StoreID })
+	return status
+}
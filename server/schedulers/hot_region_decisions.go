@@ -0,0 +1,177 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/pd/server/core"
+)
+
+// defaultDecisionLogCapacity is the number of the latest decisions kept in
+// the decision log by default, see "decision-log-size".
+const defaultDecisionLogCapacity = 256
+
+// hotDecisionCandidate is a candidate destination store of a decision, with
+// its hot load when the decision is made.
+type hotDecisionCandidate struct {
+	StoreID uint64 `json:"store-id"`
+	// Flow is the flow of the store in the balanced dimension.
+	Flow           uint64 `json:"flow"`
+	HotRegionCount int    `json:"hot-region-count"`
+}
+
+// hotDecisionPrediction is the prediction of the model service asked for a
+// decision.
+type hotDecisionPrediction struct {
+	SrcStoreID  uint64  `json:"src-store-id"`
+	DestStoreID uint64  `json:"dest-store-id"`
+	Probability float64 `json:"probability"`
+}
+
+// hotDecisionRecord is a decision made for a hot region, whether or not the
+// region is moved by it.
+type hotDecisionRecord struct {
+	Time          time.Time              `json:"time"`
+	BalanceType   string                 `json:"balance-type"`
+	RegionID      uint64                 `json:"region-id"`
+	SourceStoreID uint64                 `json:"source-store-id"`
+	Candidates    []hotDecisionCandidate `json:"candidates"`
+	// DestStoreID is the chosen destination, 0 if no candidate is chosen.
+	DestStoreID uint64 `json:"dest-store-id"`
+	// Strategy is the reason why the heuristics choose the destination.
+	Strategy string `json:"strategy,omitempty"`
+	Relaxed  bool   `json:"relaxed,omitempty"`
+	// Features are the features of the decision sent to the model service.
+	Features []Feature `json:"features,omitempty"`
+	// Prediction is nil if the model service is not asked before the
+	// decision, which is the case unless the model mode is active.
+	Prediction *hotDecisionPrediction `json:"prediction,omitempty"`
+	// Emitted is whether an operator is created by the decision.
+	Emitted bool `json:"emitted"`
+}
+
+// hotDecisionLog is a ring buffer of the latest decisions, the oldest one is
+// overwritten when it is full. It is not thread-safe, the scheduler protects
+// it with its own lock.
+type hotDecisionLog struct {
+	records []hotDecisionRecord
+	// next is the index to write the next record to.
+	next int
+	full bool
+}
+
+func newHotDecisionLog(capacity int) *hotDecisionLog {
+	return &hotDecisionLog{records: make([]hotDecisionRecord, capacity)}
+}
+
+// add appends the record and returns its index.
+func (l *hotDecisionLog) add(record hotDecisionRecord) int {
+	idx := l.next
+	l.records[idx] = record
+	l.next++
+	if l.next == len(l.records) {
+		l.next = 0
+		l.full = true
+	}
+	return idx
+}
+
+func (l *hotDecisionLog) markEmitted(idx int) {
+	if idx >= 0 && idx < len(l.records) {
+		l.records[idx].Emitted = true
+	}
+}
+
+// latest returns the records from the latest to the oldest, only those of
+// the region if regionID is not 0.
+func (l *hotDecisionLog) latest(regionID uint64) []hotDecisionRecord {
+	n := l.next
+	if l.full {
+		n = len(l.records)
+	}
+	records := make([]hotDecisionRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		record := l.records[(l.next-i+len(l.records))%len(l.records)]
+		if regionID == 0 || record.RegionID == regionID {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// decisionCandidates describes the hot load of the candidate stores.
+func (h *balanceHotRegionsScheduler) decisionCandidates(candidateStoreIDs []uint64, storesStat core.StoreHotRegionsStat) []hotDecisionCandidate {
+	candidates := make([]hotDecisionCandidate, 0, len(candidateStoreIDs))
+	for _, storeID := range candidateStoreIDs {
+		candidate := hotDecisionCandidate{StoreID: storeID}
+		if s, ok := storesStat[storeID]; ok {
+			candidate.Flow, candidate.HotRegionCount = h.storeFlow(s), s.RegionsStat.Len()
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+func newDecisionPrediction(prediction *Prediction) *hotDecisionPrediction {
+	if prediction == nil {
+		return nil
+	}
+	return &hotDecisionPrediction{
+		SrcStoreID:  prediction.SrcStoreID,
+		DestStoreID: prediction.DestStoreID,
+		Probability: prediction.Probability,
+	}
+}
+
+// logDecision appends the decision to the decision log, it is marked
+// emitted by recordDecision once the operator is created. The hypothetical
+// decisions are never logged.
+func (h *balanceHotRegionsScheduler) logDecision(record hotDecisionRecord) {
+	h.lastScore, h.lastStrategy = decisionScore(record), record.Strategy
+	if h.hypothetical() {
+		return
+	}
+	record.Time = h.now()
+	record.BalanceType = h.balanceType.String()
+	h.lastDecision = h.decisionLog.add(record)
+}
+
+// GetDecisions returns the latest decisions from the latest to the oldest,
+// only those of the region if regionID is not 0.
+func (h *balanceHotRegionsScheduler) GetDecisions(regionID uint64) []hotDecisionRecord {
+	h.RLock()
+	defer h.RUnlock()
+	return h.decisionLog.latest(regionID)
+}
+
+// serveDecisions serves the GET request of the decision log, the optional
+// "region_id" query filters the decisions of the region.
+func (h *balanceHotRegionsScheduler) serveDecisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeHotRegionJSON(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var regionID uint64
+	if s := r.URL.Query().Get("region_id"); s != "" {
+		var err error
+		if regionID, err = strconv.ParseUint(s, 10, 64); err != nil || regionID == 0 {
+			writeHotRegionJSON(w, http.StatusBadRequest, "invalid region_id "+strconv.Quote(s))
+			return
+		}
+	}
+	writeHotRegionJSON(w, http.StatusOK, h.GetDecisions(regionID))
+}
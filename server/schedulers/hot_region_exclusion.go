@@ -0,0 +1,224 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/kvproto/pkg/metapb"
+	"github.com/pingcap/pd/server/core"
+	"github.com/pkg/errors"
+)
+
+// storeMatcher decides whether a store is excluded by a rule. Only the exact
+// label match is supported for now.
+type storeMatcher interface {
+	Match(store *core.StoreInfo) bool
+}
+
+// labelMatcher matches the stores having the label, the value is compared
+// case-insensitively like the leader label.
+type labelMatcher struct {
+	key, value string
+}
+
+func (m labelMatcher) Match(store *core.StoreInfo) bool {
+	return strings.EqualFold(store.GetLabelValue(m.key), m.value)
+}
+
+// storeExclusion is the stores excluded from both the source and destination
+// of the hot region scheduling. The labels are evaluated against the stores
+// in every dispatch, so a replaced store with the same labels stays excluded.
+type storeExclusion struct {
+	storeIDs map[uint64]struct{}
+	labels   []*metapb.StoreLabel
+	matchers []storeMatcher
+}
+
+func newStoreExclusion(cfg core.HotStoreExclusion) (storeExclusion, error) {
+	e := storeExclusion{storeIDs: make(map[uint64]struct{}, len(cfg.StoreIDs))}
+	for _, id := range cfg.StoreIDs {
+		if id == 0 {
+			return storeExclusion{}, errors.New("invalid excluded store id 0")
+		}
+		e.storeIDs[id] = struct{}{}
+	}
+	for _, label := range cfg.Labels {
+		if label == nil || label.GetKey() == "" {
+			return storeExclusion{}, errors.Errorf("invalid excluded store label %v", label)
+		}
+		e.labels = append(e.labels, &metapb.StoreLabel{Key: label.GetKey(), Value: label.GetValue()})
+		e.matchers = append(e.matchers, labelMatcher{key: label.GetKey(), value: label.GetValue()})
+	}
+	return e, nil
+}
+
+// String implements fmt.Stringer for the change log.
+func (e storeExclusion) String() string {
+	cfg := e.config()
+	labels := make([]string, 0, len(cfg.Labels))
+	for _, label := range cfg.Labels {
+		labels = append(labels, label.GetKey()+"="+label.GetValue())
+	}
+	return fmt.Sprintf("stores=%v,labels=%v", cfg.StoreIDs, labels)
+}
+
+func (e storeExclusion) excludes(store *core.StoreInfo) bool {
+	if _, ok := e.storeIDs[store.GetId()]; ok {
+		return true
+	}
+	for _, m := range e.matchers {
+		if m.Match(store) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludedStores returns the IDs of the excluded stores, nil if none is.
+func (e storeExclusion) excludedStores(stores []*core.StoreInfo) map[uint64]struct{} {
+	var excluded map[uint64]struct{}
+	for _, store := range stores {
+		if e.excludes(store) {
+			if excluded == nil {
+				excluded = make(map[uint64]struct{})
+			}
+			excluded[store.GetId()] = struct{}{}
+		}
+	}
+	return excluded
+}
+
+func (e storeExclusion) config() core.HotStoreExclusion {
+	cfg := core.HotStoreExclusion{}
+	for id := range e.storeIDs {
+		cfg.StoreIDs = append(cfg.StoreIDs, id)
+	}
+	sort.Slice(cfg.StoreIDs, func(i, j int) bool { return cfg.StoreIDs[i] < cfg.StoreIDs[j] })
+	for _, label := range e.labels {
+		cfg.Labels = append(cfg.Labels, &metapb.StoreLabel{Key: label.GetKey(), Value: label.GetValue()})
+	}
+	return cfg
+}
+
+// status returns the exclusion in the hot status, nil if nothing is excluded.
+func (e storeExclusion) status() *core.HotStoreExclusion {
+	if len(e.storeIDs) == 0 && len(e.labels) == 0 {
+		return nil
+	}
+	cfg := e.config()
+	return &cfg
+}
+
+// parseExcludeStores parses the store IDs separated by ",".
+func parseExcludeStores(s string) ([]uint64, error) {
+	var ids []uint64
+	for _, part := range strings.Split(s, ",") {
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseExcludeLabels parses the labels in "key:value" format separated by ",".
+func parseExcludeLabels(s string) ([]*metapb.StoreLabel, error) {
+	var labels []*metapb.StoreLabel
+	for _, part := range strings.Split(s, ",") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.Errorf("invalid excluded store label %q", part)
+		}
+		labels = append(labels, &metapb.StoreLabel{Key: kv[0], Value: kv[1]})
+	}
+	return labels, nil
+}
+
+// GetStoreExclusion returns the stores excluded from the scheduling.
+func (h *balanceHotRegionsScheduler) GetStoreExclusion() core.HotStoreExclusion {
+	h.RLock()
+	defer h.RUnlock()
+	return h.exclusion.config()
+}
+
+// SetStoreExclusion replaces the stores excluded from the scheduling from the
+// next dispatch.
+func (h *balanceHotRegionsScheduler) SetStoreExclusion(cfg core.HotStoreExclusion) error {
+	exclusion, err := newStoreExclusion(cfg)
+	if err != nil {
+		return err
+	}
+	h.Lock()
+	defer h.Unlock()
+	return h.setTunable("exclusion", exclusion, core.HotChangeSourceUser, hotConfigUpdateReason)
+}
+
+// AddTargetBlacklist stops moving the hot peers and leaders to the store from
+// the next dispatch, e.g. during its maintenance.
+func (h *balanceHotRegionsScheduler) AddTargetBlacklist(storeID uint64) error {
+	h.Lock()
+	defer h.Unlock()
+	if _, ok := h.targetBlacklist[storeID]; ok {
+		return nil
+	}
+	blacklist := make(map[uint64]struct{}, len(h.targetBlacklist)+1)
+	for id := range h.targetBlacklist {
+		blacklist[id] = struct{}{}
+	}
+	blacklist[storeID] = struct{}{}
+	return h.setTunable("target-blacklist", blacklist, core.HotChangeSourceUser, hotConfigUpdateReason)
+}
+
+// RemoveTargetBlacklist moves the hot peers and leaders to the store again
+// from the next dispatch.
+func (h *balanceHotRegionsScheduler) RemoveTargetBlacklist(storeID uint64) error {
+	h.Lock()
+	defer h.Unlock()
+	if _, ok := h.targetBlacklist[storeID]; !ok {
+		return nil
+	}
+	blacklist := make(map[uint64]struct{}, len(h.targetBlacklist))
+	for id := range h.targetBlacklist {
+		if id != storeID {
+			blacklist[id] = struct{}{}
+		}
+	}
+	return h.setTunable("target-blacklist", blacklist, core.HotChangeSourceUser, hotConfigUpdateReason)
+}
+
+// excludedTargetStores returns the stores no hot peer or leader is moved to:
+// the stores matched by the exclusion and the target blacklist. It is nil if
+// there is none.
+func (h *balanceHotRegionsScheduler) excludedTargetStores() map[uint64]struct{} {
+	var excluded map[uint64]struct{}
+	for _, stores := range []map[uint64]struct{}{h.excludedStores, h.targetBlacklist} {
+		for id := range stores {
+			if excluded == nil {
+				excluded = make(map[uint64]struct{})
+			}
+			excluded[id] = struct{}{}
+		}
+	}
+	return excluded
+}
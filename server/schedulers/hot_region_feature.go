@@ -0,0 +1,125 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// featureTypeCategory is the type of the features sent to the model
+	// service.
+	featureTypeCategory = "Category"
+	// featureTypeUnregistered is the type of the sentinel feature built from
+	// an unregistered name.
+	featureTypeUnregistered = "Unregistered"
+)
+
+// featureIndexVerb is replaced by the index of an indexed feature name.
+const featureIndexVerb = "%d"
+
+// The names of the features sent to the model service. The names containing
+// featureIndexVerb are indexed by the store ID or the candidate slot.
+const (
+	featureHotRegionsCount       = "hotRegionsCount%d"
+	featureMinRegionsCount       = "minRegionsCount%d"
+	featureMinFlowBytes          = "minFlowBytes%d"
+	featureSrcFlowBytes          = "srcFlowBytes%d"
+	featureSrcRegion             = "srcRegion"
+	featureCandidateStore        = "candidate%dStore"
+	featureCandidateFewerRegions = "candidate%dFewerRegions"
+	featureCandidateLessFlow     = "candidate%dLessFlow"
+	featureCandidateNoHotRegion  = "candidate%dNoHotRegion"
+	featureLeaderLabel           = "leaderLabel"
+	featureRelaxed               = "relaxed"
+	featureFlowBytesP99          = "flowBytesP99"
+	featureCoprocessorFlowBytes  = "coprocessorFlowBytes"
+	featureGetFlowBytes          = "getFlowBytes"
+	featureDestApplyLoad         = "destApplyLoad"
+	featureSchemaVersion         = "schemaVersion"
+)
+
+// FeatureRegistry is the schema of the features sent to the model service,
+// the features are built from the registered names only.
+type FeatureRegistry struct {
+	types map[string]string
+}
+
+// NewFeatureRegistry creates an empty registry.
+func NewFeatureRegistry() *FeatureRegistry {
+	return &FeatureRegistry{types: make(map[string]string)}
+}
+
+// Register registers the name with the feature type. A name containing
+// "%d" is indexed, the index is filled in when the feature is built.
+func (r *FeatureRegistry) Register(name string, featureType string) {
+	r.types[name] = featureType
+}
+
+// Build builds the feature of the registered name. The index fills in an
+// indexed name and is ignored otherwise. A sentinel feature of the type
+// "Unregistered" is built if the name is not registered, which is reported
+// by Validate.
+func (r *FeatureRegistry) Build(name string, index uint64, value string) Feature {
+	featureType, ok := r.types[name]
+	if !ok {
+		return Feature{FeatureType: featureTypeUnregistered, Name: name, Value: value}
+	}
+	if strings.Contains(name, featureIndexVerb) {
+		name = strings.Replace(name, featureIndexVerb, strconv.FormatUint(index, 10), 1)
+	}
+	return Feature{FeatureType: featureType, Name: name, Value: value}
+}
+
+// Validate checks that none of the features is built from an unregistered
+// name.
+func (r *FeatureRegistry) Validate(features []Feature) error {
+	for _, f := range features {
+		if f.FeatureType == featureTypeUnregistered {
+			return errors.Errorf("unregistered feature %q", f.Name)
+		}
+	}
+	return nil
+}
+
+// hotFeatures is the schema of the features of the hot region scheduler.
+var hotFeatures = newHotFeatureRegistry()
+
+func newHotFeatureRegistry() *FeatureRegistry {
+	r := NewFeatureRegistry()
+	for _, name := range []string{
+		featureHotRegionsCount,
+		featureMinRegionsCount,
+		featureMinFlowBytes,
+		featureSrcFlowBytes,
+		featureSrcRegion,
+		featureCandidateStore,
+		featureCandidateFewerRegions,
+		featureCandidateLessFlow,
+		featureCandidateNoHotRegion,
+		featureLeaderLabel,
+		featureRelaxed,
+		featureFlowBytesP99,
+		featureCoprocessorFlowBytes,
+		featureGetFlowBytes,
+		featureDestApplyLoad,
+		featureSchemaVersion,
+	} {
+		r.Register(name, featureTypeCategory)
+	}
+	return r
+}
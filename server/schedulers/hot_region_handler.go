@@ -0,0 +1,366 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// hotConfigUpdateReason is the reason of the changes made through the API in
+// the change log.
+const hotConfigUpdateReason = "updated by the API"
+
+// hotRegionSchedulerConfig is the config of the scheduler served by ServeHTTP.
+// The read and write limits are adjusted by the scheduler itself from the
+// limit, so they are read only. The model URL and the minimal region flow
+// bytes are the ones of the last dispatch, they follow the cluster
+// configuration until they are set.
+type hotRegionSchedulerConfig struct {
+	ReadLimit          uint64  `json:"read-limit"`
+	WriteLimit         uint64  `json:"write-limit"`
+	Limit              uint64  `json:"limit"`
+	MaxLimit           uint64  `json:"max-limit"`
+	ScheduleFactor     float64 `json:"schedule-factor"`
+	LimitFactor        float64 `json:"limit-factor"`
+	ModelMode          string  `json:"model-mode"`
+	BalanceMode        string  `json:"balance-mode"`
+	ModelThreshold     float64 `json:"model-threshold"`
+	ModelURL           string  `json:"model-url"`
+	MinRegionFlowBytes uint64  `json:"min-region-flow-bytes"`
+	KeysWeight         float64 `json:"keys-weight"`
+	RetryLimit         int     `json:"retry-limit"`
+	StartKey           string  `json:"start-key"`
+	EndKey             string  `json:"end-key"`
+}
+
+// hotRegionSchedulerState is the response of the GET request.
+type hotRegionSchedulerState struct {
+	Config hotRegionSchedulerConfig  `json:"config"`
+	Read   *core.StoreHotRegionInfos `json:"read"`
+	Write  *core.StoreHotRegionInfos `json:"write"`
+	// LastBalanceType is the balance type of the last dispatch, empty if
+	// there is none.
+	LastBalanceType string `json:"last-balance-type,omitempty"`
+	// ModelBreaker is the circuit breaker of the model service, the
+	// predictions stop while it is not closed.
+	ModelBreaker modelBreakerStatus `json:"model-breaker"`
+	// ModelDisabled is whether the kill switch of the model service is on,
+	// no request is sent to it then.
+	ModelDisabled bool `json:"model-disabled"`
+	// ModelSchema is the schema of the features sent to the model service.
+	ModelSchema modelSchemaStatus `json:"model-schema"`
+	// Memory is the estimated memory of each auxiliary structure in bytes.
+	Memory map[string]uint64 `json:"memory"`
+}
+
+// hotRegionSchedulerConfigUpdate is the body of the POST request, the omitted
+// fields are not changed.
+type hotRegionSchedulerConfigUpdate struct {
+	// Limit is the minimal limit of the running hot region operators, at
+	// least 1. MaxLimit clamps the adjusted limit, 0 means no clamp.
+	Limit          *uint64  `json:"limit"`
+	MaxLimit       *uint64  `json:"max-limit"`
+	ScheduleFactor *float64 `json:"schedule-factor"`
+	LimitFactor    *float64 `json:"limit-factor"`
+	ModelMode      *string  `json:"model-mode"`
+	ModelThreshold *float64 `json:"model-threshold"`
+	// ModelURL overrides the cluster configuration "hot-region-model-url"
+	// like the argument model-endpoint, empty means no model service.
+	ModelURL *string `json:"model-url"`
+	// MinRegionFlowBytes overrides the cluster configuration
+	// "hot-region-min-flow-bytes", 0 means no region is filtered.
+	MinRegionFlowBytes *uint64 `json:"min-region-flow-bytes"`
+	// KeysWeight is the weight of the flow keys in the score of the stores,
+	// 0 balances either the bytes or the keys by the imbalance.
+	KeysWeight *float64 `json:"keys-weight"`
+	// RetryLimit is the number of the attempts to find a hot write region
+	// to balance in a dispatch, in [1, 100].
+	RetryLimit *int `json:"retry-limit"`
+	// StartKey and EndKey are the hex-encoded keys of the range of the hot
+	// regions scheduled, empty for unbounded.
+	StartKey *string `json:"start-key"`
+	EndKey   *string `json:"end-key"`
+}
+
+// ServeHTTP implements http.Handler. GET returns the config and the status of
+// the scheduler, and POST changes the config partially. The changes are
+// persisted if the scheduler has a config storage. GET "/decisions" returns
+// the decision log, and GET "/debug" returns the debug dump.
+func (h *balanceHotRegionsScheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimSuffix(r.URL.Path, "/") {
+	case "":
+	case "/decisions":
+		h.serveDecisions(w, r)
+		return
+	case "/debug":
+		if r.Method != http.MethodGet {
+			writeHotRegionJSON(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := h.DebugDump(w); err != nil {
+			log.Errorf("[%s] failed to write the debug dump: %v", h.GetName(), err)
+		}
+		return
+	default:
+		writeHotRegionJSON(w, http.StatusNotFound, "not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		read, write := h.GetHotStatus()
+		state := hotRegionSchedulerState{Config: h.config(), Read: read, Write: write, ModelBreaker: h.breaker.status(), ModelDisabled: h.isModelDisabled(), ModelSchema: h.schemaStatus(), Memory: h.MemoryFootprint()}
+		if typ, ok := h.LastBalanceType(); ok {
+			state.LastBalanceType = typ.String()
+		}
+		writeHotRegionJSON(w, http.StatusOK, state)
+	case http.MethodPost:
+		var update hotRegionSchedulerConfigUpdate
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&update); err != nil {
+			writeHotRegionJSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := h.updateConfig(update); err != nil {
+			writeHotRegionJSON(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeHotRegionJSON(w, http.StatusOK, h.config())
+	default:
+		writeHotRegionJSON(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (h *balanceHotRegionsScheduler) isModelDisabled() bool {
+	h.RLock()
+	defer h.RUnlock()
+	return modelDisabledByEnv || h.modelDisabled
+}
+
+func (h *balanceHotRegionsScheduler) config() hotRegionSchedulerConfig {
+	h.RLock()
+	defer h.RUnlock()
+	return hotRegionSchedulerConfig{
+		ReadLimit:          h.readLimit,
+		WriteLimit:         h.writeLimit,
+		Limit:              h.baseLimit,
+		MaxLimit:           h.maxLimit,
+		ScheduleFactor:     h.scheduleFactor,
+		LimitFactor:        h.limitFactor,
+		ModelMode:          h.modelMode.String(),
+		BalanceMode:        h.balanceMode.String(),
+		ModelThreshold:     h.modelThreshold,
+		ModelURL:           h.modelURL,
+		MinRegionFlowBytes: h.minRegionFlowBytes,
+		KeysWeight:         h.keysWeight,
+		RetryLimit:         h.retryLimit,
+		StartKey:           hex.EncodeToString(h.keyRange.startKey),
+		EndKey:             hex.EncodeToString(h.keyRange.endKey),
+	}
+}
+
+// updateConfig applies the update, nothing is changed if any field is
+// invalid.
+func (h *balanceHotRegionsScheduler) updateConfig(update hotRegionSchedulerConfigUpdate) error {
+	var (
+		factors core.HotRegionFactors
+		model   core.HotModelConfig
+	)
+	// The zero values mean unchanged for the setters, so they are rejected
+	// explicitly.
+	for _, f := range []struct {
+		name  string
+		value *float64
+		dest  *float64
+	}{
+		{"schedule-factor", update.ScheduleFactor, &factors.ScheduleFactor},
+		{"limit-factor", update.LimitFactor, &factors.LimitFactor},
+		{"model-threshold", update.ModelThreshold, &model.Threshold},
+	} {
+		if f.value == nil {
+			continue
+		}
+		if *f.value == 0 {
+			return errors.Errorf("invalid %s 0, it must be in (0, 1]", f.name)
+		}
+		*f.dest = *f.value
+	}
+	if update.ModelMode != nil {
+		if *update.ModelMode == "" {
+			return errors.New("invalid empty model-mode")
+		}
+		model.Mode = *update.ModelMode
+	}
+	if update.Limit != nil && *update.Limit == 0 {
+		return errors.New("invalid limit 0, it must be at least 1")
+	}
+	var modelURL string
+	if update.ModelURL != nil {
+		var err error
+		if modelURL, err = parseModelEndpoint(*update.ModelURL); err != nil {
+			return err
+		}
+	}
+	// SetModelConfig validates the model config before changing it.
+	if err := validateHotRegionFactors(factors); err != nil {
+		return err
+	}
+	if update.KeysWeight != nil {
+		if err := validateKeysWeight(*update.KeysWeight); err != nil {
+			return err
+		}
+	}
+	if update.RetryLimit != nil {
+		if err := validateRetryLimit(*update.RetryLimit); err != nil {
+			return err
+		}
+	}
+	keyRange, err := h.updatedKeyRange(update.StartKey, update.EndKey)
+	if err != nil {
+		return err
+	}
+	if err := h.SetModelConfig(model); err != nil {
+		return err
+	}
+	if update.Limit != nil || update.MaxLimit != nil {
+		if err := h.setLimits(update.Limit, update.MaxLimit); err != nil {
+			return err
+		}
+	}
+	if update.ModelURL != nil {
+		if err := h.setModelEndpoint(modelURL); err != nil {
+			return err
+		}
+	}
+	if update.MinRegionFlowBytes != nil {
+		if err := h.setMinRegionFlowBytes(*update.MinRegionFlowBytes); err != nil {
+			return err
+		}
+	}
+	if update.KeysWeight != nil {
+		if err := h.setKeysWeight(*update.KeysWeight); err != nil {
+			return err
+		}
+	}
+	if update.RetryLimit != nil {
+		if err := h.setRetryLimit(*update.RetryLimit); err != nil {
+			return err
+		}
+	}
+	if update.StartKey != nil || update.EndKey != nil {
+		if err := h.setKeyRange(keyRange); err != nil {
+			return err
+		}
+	}
+	return h.SetFactors(factors)
+}
+
+// setLimits changes the limit and the clamp of the adjusted limits, the nil
+// one is not changed. The read and write limits are raised to the limit at
+// once, and adjusted from the next dispatch.
+func (h *balanceHotRegionsScheduler) setLimits(limit, maxLimit *uint64) error {
+	h.Lock()
+	defer h.Unlock()
+	if maxLimit != nil {
+		if err := h.setTunable("max-limit", *maxLimit, core.HotChangeSourceUser, hotConfigUpdateReason); err != nil {
+			return err
+		}
+	}
+	if limit == nil {
+		return nil
+	}
+	if err := h.setTunable("limit", *limit, core.HotChangeSourceUser, hotConfigUpdateReason); err != nil {
+		return err
+	}
+	if err := h.setTunable("read-limit", maxUint64(h.readLimit, *limit), core.HotChangeSourceUser, hotConfigUpdateReason); err != nil {
+		return err
+	}
+	return h.setTunable("write-limit", maxUint64(h.writeLimit, *limit), core.HotChangeSourceUser, hotConfigUpdateReason)
+}
+
+// setModelEndpoint overrides the cluster configuration of the model service
+// URL from the next dispatch.
+func (h *balanceHotRegionsScheduler) setModelEndpoint(endpoint string) error {
+	h.Lock()
+	defer h.Unlock()
+	return h.setTunable("model-endpoint", &endpoint, core.HotChangeSourceUser, hotConfigUpdateReason)
+}
+
+// setMinRegionFlowBytes overrides the cluster configuration from now on.
+func (h *balanceHotRegionsScheduler) setMinRegionFlowBytes(flowBytes uint64) error {
+	h.Lock()
+	defer h.Unlock()
+	if err := h.setTunable("min-region-flow-bytes-override", &flowBytes, core.HotChangeSourceUser, hotConfigUpdateReason); err != nil {
+		return err
+	}
+	return h.setTunable("min-region-flow-bytes", flowBytes, core.HotChangeSourceUser, hotConfigUpdateReason)
+}
+
+// setKeysWeight changes the weight of the flow keys from the next decision.
+func (h *balanceHotRegionsScheduler) setKeysWeight(weight float64) error {
+	h.Lock()
+	defer h.Unlock()
+	return h.setTunable("keys-weight", weight, core.HotChangeSourceUser, hotConfigUpdateReason)
+}
+
+// setRetryLimit changes the limit to retry schedule from the next dispatch.
+func (h *balanceHotRegionsScheduler) setRetryLimit(limit int) error {
+	h.Lock()
+	defer h.Unlock()
+	return h.setTunable("retry-limit", limit, core.HotChangeSourceUser, hotConfigUpdateReason)
+}
+
+// updatedKeyRange returns the key range with the keys updated, the omitted
+// keys are not changed.
+func (h *balanceHotRegionsScheduler) updatedKeyRange(startKey, endKey *string) (hotKeyRange, error) {
+	h.RLock()
+	keyRange := h.keyRange
+	h.RUnlock()
+	var err error
+	if startKey != nil {
+		if keyRange.startKey, err = parseHexKey("start-key", *startKey); err != nil {
+			return hotKeyRange{}, err
+		}
+	}
+	if endKey != nil {
+		if keyRange.endKey, err = parseHexKey("end-key", *endKey); err != nil {
+			return hotKeyRange{}, err
+		}
+	}
+	return keyRange, keyRange.validate()
+}
+
+// setKeyRange changes the key range of the hot regions scheduled from the
+// next dispatch.
+func (h *balanceHotRegionsScheduler) setKeyRange(keyRange hotKeyRange) error {
+	h.Lock()
+	defer h.Unlock()
+	return h.setTunable("key-range", keyRange, core.HotChangeSourceUser, hotConfigUpdateReason)
+}
+
+func writeHotRegionJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("[%s] failed to write the response: %v", hotRegionSchedulerName, err)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pingcap/pd/server/core"
+)
+
+// defaultHotRegionHistoryCapacity is the number of the latest decisions kept
+// by the hot region scheduler.
+const defaultHotRegionHistoryCapacity = 1000
+
+// hotRegionHistory is a ring buffer of the latest decisions, the oldest one
+// is overwritten when it is full. It is not thread-safe, the scheduler
+// protects it with its own lock.
+type hotRegionHistory struct {
+	decisions []core.HotRegionDecision
+	// next is the index to write the next decision to.
+	next int
+	full bool
+	// version is increased by every added decision.
+	version uint64
+}
+
+func newHotRegionHistory(capacity int) *hotRegionHistory {
+	return &hotRegionHistory{decisions: make([]core.HotRegionDecision, capacity)}
+}
+
+func (r *hotRegionHistory) add(decision core.HotRegionDecision) {
+	r.decisions[r.next] = decision
+	r.version++
+	r.next++
+	if r.next == len(r.decisions) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+func (r *hotRegionHistory) len() int {
+	if r.full {
+		return len(r.decisions)
+	}
+	return r.next
+}
+
+// latest returns at most limit decisions from the latest to the oldest,
+// all the decisions are returned if limit is not positive.
+func (r *hotRegionHistory) latest(limit int) []core.HotRegionDecision {
+	n := r.len()
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	decisions := make([]core.HotRegionDecision, 0, n)
+	for i := 1; i <= n; i++ {
+		idx := (r.next - i + len(r.decisions)) % len(r.decisions)
+		decisions = append(decisions, r.decisions[idx])
+	}
+	return decisions
+}
+
+// recordDecision appends the decision of the created operator to the history,
+// with the features of the current decision, and marks the decision emitted
+// in the decision log.
+func (h *balanceHotRegionsScheduler) recordDecision(typ BalanceType, region *core.RegionInfo, storesStat core.StoreHotRegionsStat, srcStoreID, destStoreID uint64) {
+	if h.preview {
+		return
+	}
+	h.decisionLog.markEmitted(h.lastDecision)
+	var features []core.HotDecisionFeature
+	if len(h.features) != 0 {
+		// The later feature of the same name wins.
+		values := make(map[string]string, len(h.features))
+		for _, f := range h.features {
+			values[f.Name] = f.Value
+		}
+		features = make([]core.HotDecisionFeature, 0, len(values))
+		for name, value := range values {
+			features = append(features, core.HotDecisionFeature{Name: name, Value: value})
+		}
+		sort.Slice(features, func(i, j int) bool { return features[i].Name < features[j].Name })
+	}
+	h.history.add(core.HotRegionDecision{
+		Time:          h.now(),
+		RegionID:      region.GetID(),
+		SourceStoreID: srcStoreID,
+		DestStoreID:   destStoreID,
+		BalanceType:   typ.String(),
+		RegionSize:    region.GetApproximateSize(),
+		FlowBytes:     hotRegionFlowBytes(region.GetID(), storesStat, srcStoreID),
+		Features:      features,
+	})
+}
+
+// GetHotRegionHistory returns at most limit latest decisions of the
+// scheduler, from the latest to the oldest. All the kept decisions are
+// returned if limit is not positive.
+func (h *balanceHotRegionsScheduler) GetHotRegionHistory(limit int) []core.HotRegionDecision {
+	h.RLock()
+	defer h.RUnlock()
+	return h.history.latest(limit)
+}
+
+// maxHotRegionReportDays is the maximal number of days in the daily report.
+const maxHotRegionReportDays = 31
+
+// hotRegionReportCache caches the daily report until a decision is added or
+// the day changes.
+type hotRegionReportCache struct {
+	version uint64
+	today   string
+	days    int
+	reports []core.HotRegionDailyReport
+}
+
+// GetHotRegionDailyReport aggregates the hot region moves in the history per
+// day and per store for the latest days, from the oldest day to today. Every
+// store moved in the days is reported in every day, with zeros if it has no
+// move on that day. Only the decisions kept in the history are aggregated,
+// and days is capped by maxHotRegionReportDays.
+func (h *balanceHotRegionsScheduler) GetHotRegionDailyReport(days int) []core.HotRegionDailyReport {
+	if days > maxHotRegionReportDays {
+		days = maxHotRegionReportDays
+	}
+	// The cache is updated, so the write lock is required.
+	h.Lock()
+	defer h.Unlock()
+	now := h.now()
+	today := now.Format(hotRegionReportDateFormat)
+	if c := h.reportCache; c != nil && c.version == h.history.version && c.today == today && c.days == days {
+		return c.reports
+	}
+	reports := aggregateHotRegionDecisions(h.history.latest(0), now, days)
+	h.reportCache = &hotRegionReportCache{
+		version: h.history.version,
+		today:   today,
+		days:    days,
+		reports: reports,
+	}
+	return reports
+}
+
+const hotRegionReportDateFormat = "2006-01-02"
+
+func aggregateHotRegionDecisions(decisions []core.HotRegionDecision, now time.Time, days int) []core.HotRegionDailyReport {
+	if days <= 0 {
+		return nil
+	}
+	reports := make([]core.HotRegionDailyReport, days)
+	index := make(map[string]int, days)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for i := range reports {
+		date := midnight.AddDate(0, 0, i-days+1).Format(hotRegionReportDateFormat)
+		reports[i] = core.HotRegionDailyReport{Date: date}
+		index[date] = i
+	}
+
+	// day index -> store id -> the report of the store on that day.
+	storeReports := make([]map[uint64]*core.HotRegionStoreReport, days)
+	storeReport := func(i int, storeID uint64) *core.HotRegionStoreReport {
+		if storeReports[i] == nil {
+			storeReports[i] = make(map[uint64]*core.HotRegionStoreReport)
+		}
+		r, ok := storeReports[i][storeID]
+		if !ok {
+			r = &core.HotRegionStoreReport{StoreID: storeID}
+			storeReports[i][storeID] = r
+		}
+		return r
+	}
+	stores := make(map[uint64]struct{})
+	for _, d := range decisions {
+		i, ok := index[d.Time.In(now.Location()).Format(hotRegionReportDateFormat)]
+		if !ok {
+			continue
+		}
+		out := storeReport(i, d.SourceStoreID)
+		out.OutMoves++
+		out.OutRegionSize += d.RegionSize
+		out.OutFlowBytes += d.FlowBytes
+		in := storeReport(i, d.DestStoreID)
+		in.InMoves++
+		in.InRegionSize += d.RegionSize
+		in.InFlowBytes += d.FlowBytes
+		stores[d.SourceStoreID] = struct{}{}
+		stores[d.DestStoreID] = struct{}{}
+	}
+	if len(stores) == 0 {
+		return reports
+	}
+	storeIDs := make([]uint64, 0, len(stores))
+	for storeID := range stores {
+		storeIDs = append(storeIDs, storeID)
+	}
+	sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i] < storeIDs[j] })
+	for i := range reports {
+		reports[i].Stores = make([]core.HotRegionStoreReport, 0, len(storeIDs))
+		for _, storeID := range storeIDs {
+			reports[i].Stores = append(reports[i].Stores, *storeReport(i, storeID))
+		}
+	}
+	return reports
+}
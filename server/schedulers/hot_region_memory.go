@@ -0,0 +1,371 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sort"
+	"time"
+	"unsafe"
+
+	"github.com/pingcap/pd/server/core"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultMemoryBudget is the default budget of the memory held by the
+// auxiliary state of the scheduler, see "memory-budget".
+const defaultMemoryBudget = 64 << 20
+
+// The auxiliary structures of the scheduler whose memory is bounded.
+const (
+	memoryHistory          = "history"
+	memoryDecisionLog      = "decision-log"
+	memoryChangeLog        = "change-log"
+	memoryReportCache      = "report-cache"
+	memoryAdvisories       = "advisories"
+	memoryPendingInfluence = "pending-influence"
+	memoryMovedRegions     = "moved-regions"
+	memoryClockSkew        = "clock-skew"
+	memoryModelUpdates     = "model-updates"
+	memoryPredictions      = "predictions"
+)
+
+var memoryStructures = []string{
+	memoryHistory,
+	memoryDecisionLog,
+	memoryChangeLog,
+	memoryReportCache,
+	memoryAdvisories,
+	memoryPendingInfluence,
+	memoryMovedRegions,
+	memoryClockSkew,
+	memoryModelUpdates,
+	memoryPredictions,
+}
+
+// The approximate sizes of the entries. The strings and the slices referred
+// by the entries are counted by their lengths, the memory shared with the
+// others, e.g. the operators of the pending influence, is not counted.
+const (
+	// mapEntryOverhead approximates the memory of a map entry besides its
+	// key and value.
+	mapEntryOverhead = 16
+
+	historyEntrySize      = uint64(unsafe.Sizeof(core.HotRegionDecision{}))
+	decisionFeatureSize   = uint64(unsafe.Sizeof(core.HotDecisionFeature{}))
+	decisionEntrySize     = uint64(unsafe.Sizeof(hotDecisionRecord{}))
+	candidateSize         = uint64(unsafe.Sizeof(hotDecisionCandidate{}))
+	predictionSize        = uint64(unsafe.Sizeof(hotDecisionPrediction{}))
+	featureSize           = uint64(unsafe.Sizeof(Feature{}))
+	changeEntrySize       = uint64(unsafe.Sizeof(core.HotSchedulerChange{}))
+	dailyReportSize       = uint64(unsafe.Sizeof(core.HotRegionDailyReport{}))
+	storeReportSize       = uint64(unsafe.Sizeof(core.HotRegionStoreReport{}))
+	advisoryEntrySize     = uint64(unsafe.Sizeof(uint64(0))+unsafe.Sizeof(core.HotRegionAdvisory{})) + mapEntryOverhead
+	pendingEntrySize      = uint64(unsafe.Sizeof(uint64(0))+unsafe.Sizeof(uintptr(0))+unsafe.Sizeof(pendingInfluence{})) + mapEntryOverhead
+	movedRegionEntrySize  = uint64(unsafe.Sizeof(uint64(0))+unsafe.Sizeof(time.Time{})) + mapEntryOverhead
+	clockSkewEntrySize    = uint64(unsafe.Sizeof(uint64(0))+unsafe.Sizeof(uintptr(0))+unsafe.Sizeof(storeClockSkew{})) + mapEntryOverhead
+	modelUpdateEntrySize  = uint64(unsafe.Sizeof(modelUpdateKey{})+unsafe.Sizeof(uintptr(0))+unsafe.Sizeof(pendingModelUpdate{})) + mapEntryOverhead
+	predictionEntrySize   = uint64(unsafe.Sizeof(predictionKey{})+unsafe.Sizeof(uintptr(0))+unsafe.Sizeof(cachedPrediction{})) + mapEntryOverhead
+	modelPredictionSize   = uint64(unsafe.Sizeof(Prediction{}))
+	reportCacheFixedBytes = uint64(unsafe.Sizeof(hotRegionReportCache{}))
+)
+
+func featuresSize(features []Feature) uint64 {
+	size := uint64(len(features)) * featureSize
+	for _, f := range features {
+		size += uint64(len(f.FeatureType) + len(f.Name) + len(f.Value))
+	}
+	return size
+}
+
+func (r *hotRegionHistory) memory() uint64 {
+	size := uint64(len(r.decisions)) * historyEntrySize
+	for _, d := range r.decisions {
+		size += uint64(len(d.BalanceType)) + uint64(len(d.Features))*decisionFeatureSize
+		for _, f := range d.Features {
+			size += uint64(len(f.Name) + len(f.Value))
+		}
+	}
+	return size
+}
+
+// shrink keeps at most capacity latest decisions from now on.
+func (r *hotRegionHistory) shrink(capacity int) {
+	if capacity >= len(r.decisions) {
+		return
+	}
+	latest := r.latest(capacity)
+	shrunk := newHotRegionHistory(capacity)
+	for i := len(latest) - 1; i >= 0; i-- {
+		shrunk.add(latest[i])
+	}
+	// The decisions are changed, so is the version.
+	shrunk.version = r.version + 1
+	*r = *shrunk
+}
+
+func (l *hotDecisionLog) memory() uint64 {
+	size := uint64(len(l.records)) * decisionEntrySize
+	for _, r := range l.records {
+		size += uint64(len(r.BalanceType)+len(r.Strategy)) + uint64(len(r.Candidates))*candidateSize + featuresSize(r.Features)
+		if r.Prediction != nil {
+			size += predictionSize
+		}
+	}
+	return size
+}
+
+// shrink keeps at most capacity latest records from now on.
+func (l *hotDecisionLog) shrink(capacity int) {
+	if capacity >= len(l.records) {
+		return
+	}
+	latest := l.latest(0)
+	if len(latest) > capacity {
+		latest = latest[:capacity]
+	}
+	shrunk := newHotDecisionLog(capacity)
+	for i := len(latest) - 1; i >= 0; i-- {
+		shrunk.add(latest[i])
+	}
+	*l = *shrunk
+}
+
+func (l *hotChangeLog) memory() uint64 {
+	l.Lock()
+	defer l.Unlock()
+	size := uint64(len(l.changes)) * changeEntrySize
+	for _, c := range l.changes {
+		size += uint64(len(c.Name) + len(c.Old) + len(c.New) + len(c.Reason))
+	}
+	return size
+}
+
+// capacity returns the number of the changes the log keeps.
+func (l *hotChangeLog) capacity() int {
+	l.Lock()
+	defer l.Unlock()
+	return len(l.changes)
+}
+
+// shrink keeps at most capacity latest changes from now on.
+func (l *hotChangeLog) shrink(capacity int) {
+	l.Lock()
+	defer l.Unlock()
+	if capacity >= len(l.changes) {
+		return
+	}
+	n := l.next
+	if l.full {
+		n = len(l.changes)
+	}
+	if n > capacity {
+		n = capacity
+	}
+	changes := make([]core.HotSchedulerChange, capacity)
+	for i := 1; i <= n; i++ {
+		changes[n-i] = l.changes[(l.next-i+len(l.changes))%len(l.changes)]
+	}
+	l.changes, l.next, l.full = changes, n%capacity, n == capacity
+}
+
+func (c *hotRegionReportCache) memory() uint64 {
+	if c == nil {
+		return 0
+	}
+	size := reportCacheFixedBytes + uint64(len(c.today))
+	for _, r := range c.reports {
+		size += dailyReportSize + uint64(len(r.Date)) + uint64(len(r.Stores))*storeReportSize
+	}
+	return size
+}
+
+func (r *modelReporter) memory() uint64 {
+	size := uint64(len(r.pending)+len(r.batch)) * modelUpdateEntrySize
+	for _, p := range r.pending {
+		size += uint64(len(p.step)) + featuresSize(p.features)
+	}
+	for _, p := range r.batch {
+		size += uint64(len(p.step)) + featuresSize(p.features)
+	}
+	return size
+}
+
+func (c *hotPredictionCache) memory() uint64 {
+	c.Lock()
+	defer c.Unlock()
+	size := uint64(len(c.entries)) * predictionEntrySize
+	for _, e := range c.entries {
+		if e.prediction != nil {
+			size += modelPredictionSize
+		}
+	}
+	return size
+}
+
+// evict drops the earliest asked predictions beyond maxEntries and returns
+// the number of the dropped ones.
+func (c *hotPredictionCache) evict(maxEntries int) int {
+	c.Lock()
+	defer c.Unlock()
+	if len(c.entries) <= maxEntries {
+		return 0
+	}
+	keys := make([]predictionKey, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return c.entries[keys[i]].askedAt.Before(c.entries[keys[j]].askedAt) })
+	evicted := len(keys) - maxEntries
+	for _, key := range keys[:evicted] {
+		delete(c.entries, key)
+	}
+	return evicted
+}
+
+// memoryFootprint estimates the memory of each auxiliary structure in bytes,
+// the caller must hold the lock.
+func (h *balanceHotRegionsScheduler) memoryFootprint() map[string]uint64 {
+	return map[string]uint64{
+		memoryHistory:          h.history.memory(),
+		memoryDecisionLog:      h.decisionLog.memory(),
+		memoryChangeLog:        h.changes.memory(),
+		memoryReportCache:      h.reportCache.memory(),
+		memoryAdvisories:       uint64(len(h.advisories)) * advisoryEntrySize,
+		memoryPendingInfluence: uint64(len(h.pendings)) * pendingEntrySize,
+		memoryMovedRegions:     uint64(len(h.movedRegions)) * movedRegionEntrySize,
+		memoryClockSkew:        uint64(len(h.clockSkew)) * clockSkewEntrySize,
+		memoryModelUpdates:     h.reporter.memory(),
+		memoryPredictions:      h.predictions.memory(),
+	}
+}
+
+// MemoryFootprint returns the estimated memory of each auxiliary structure
+// of the scheduler in bytes, by the number of the entries and their
+// approximate sizes.
+func (h *balanceHotRegionsScheduler) MemoryFootprint() map[string]uint64 {
+	h.RLock()
+	defer h.RUnlock()
+	return h.memoryFootprint()
+}
+
+// memoryCap is the hard cap of the memory of each auxiliary structure, the
+// budget is shared evenly.
+func (h *balanceHotRegionsScheduler) memoryCap() uint64 {
+	return h.memoryBudget / uint64(len(memoryStructures))
+}
+
+// shrunkCapacity returns the capacity of a ring buffer to fit its memory in
+// the cap, at least 1.
+func shrunkCapacity(capacity int, memory, memoryCap uint64) int {
+	shrunk := int(uint64(capacity) * memoryCap / memory)
+	if shrunk < 1 {
+		shrunk = 1
+	}
+	return shrunk
+}
+
+// evictOldest removes the oldest keys beyond maxEntries and returns the
+// number of the removed keys.
+func evictOldest(keys []uint64, maxEntries int, older func(a, b uint64) bool, remove func(key uint64)) int {
+	if len(keys) <= maxEntries {
+		return 0
+	}
+	sort.Slice(keys, func(i, j int) bool { return older(keys[i], keys[j]) })
+	evicted := len(keys) - maxEntries
+	for _, key := range keys[:evicted] {
+		remove(key)
+	}
+	return evicted
+}
+
+// enforceMemoryBudget evicts the oldest entries of the structures above the
+// cap, and exports the memory of the structures. The ring buffers are shrunk
+// for good, the pending model updates are sent, and the report cache is
+// dropped. The caller must hold the lock.
+func (h *balanceHotRegionsScheduler) enforceMemoryBudget(now time.Time) {
+	memoryCap := h.memoryCap()
+	footprint := h.memoryFootprint()
+	evictedAny := false
+	for _, name := range memoryStructures {
+		memory := footprint[name]
+		if memory <= memoryCap {
+			continue
+		}
+		var evicted int
+		switch name {
+		case memoryHistory:
+			capacity := len(h.history.decisions)
+			h.history.shrink(shrunkCapacity(capacity, memory, memoryCap))
+			evicted = capacity - len(h.history.decisions)
+		case memoryDecisionLog:
+			capacity := len(h.decisionLog.records)
+			h.decisionLog.shrink(shrunkCapacity(capacity, memory, memoryCap))
+			evicted = capacity - len(h.decisionLog.records)
+			h.lastDecision = -1
+		case memoryChangeLog:
+			capacity := h.changes.capacity()
+			h.changes.shrink(shrunkCapacity(capacity, memory, memoryCap))
+			evicted = capacity - h.changes.capacity()
+		case memoryReportCache:
+			h.reportCache, evicted = nil, 1
+		case memoryAdvisories:
+			keys := make([]uint64, 0, len(h.advisories))
+			for regionID := range h.advisories {
+				keys = append(keys, regionID)
+			}
+			evicted = evictOldest(keys, int(memoryCap/advisoryEntrySize), func(a, b uint64) bool {
+				return h.advisories[a].Time.Before(h.advisories[b].Time)
+			}, func(regionID uint64) { delete(h.advisories, regionID) })
+		case memoryPendingInfluence:
+			keys := make([]uint64, 0, len(h.pendings))
+			for regionID := range h.pendings {
+				keys = append(keys, regionID)
+			}
+			evicted = evictOldest(keys, int(memoryCap/pendingEntrySize), func(a, b uint64) bool {
+				return h.pendings[a].op.ElapsedTime() > h.pendings[b].op.ElapsedTime()
+			}, func(regionID uint64) { delete(h.pendings, regionID) })
+		case memoryMovedRegions:
+			keys := make([]uint64, 0, len(h.movedRegions))
+			for regionID := range h.movedRegions {
+				keys = append(keys, regionID)
+			}
+			evicted = evictOldest(keys, int(memoryCap/movedRegionEntrySize), func(a, b uint64) bool {
+				return h.movedRegions[a].Before(h.movedRegions[b])
+			}, func(regionID uint64) { delete(h.movedRegions, regionID) })
+		case memoryClockSkew:
+			// The stores with the fewest future-dated stats are evicted.
+			keys := make([]uint64, 0, len(h.clockSkew))
+			for storeID := range h.clockSkew {
+				keys = append(keys, storeID)
+			}
+			evicted = evictOldest(keys, int(memoryCap/clockSkewEntrySize), func(a, b uint64) bool {
+				return h.clockSkew[a].futureCount < h.clockSkew[b].futureCount
+			}, func(storeID uint64) { delete(h.clockSkew, storeID) })
+		case memoryModelUpdates:
+			evicted = len(h.reporter.pending) + len(h.reporter.batch)
+			h.reporter.flush(now, true)
+		case memoryPredictions:
+			evicted = h.predictions.evict(int(memoryCap / (predictionEntrySize + modelPredictionSize)))
+		}
+		evictedAny = true
+		hotMemoryEvictedCounter.WithLabelValues(name).Add(float64(evicted))
+		log.Warnf("[%s] %s holds %d bytes above the cap %d bytes, evict %d entries", h.GetName(), name, memory, memoryCap, evicted)
+	}
+	if evictedAny {
+		footprint = h.memoryFootprint()
+	}
+	for name, memory := range footprint {
+		hotMemoryGauge.WithLabelValues(name).Set(float64(memory))
+	}
+}
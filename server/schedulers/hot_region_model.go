@@ -0,0 +1,855 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultModelDedupWindow = 3 * time.Second
+	defaultModelBatchSize   = 50
+	defaultModelBatchDelay  = 2 * time.Second
+	defaultModelWorkers     = 8
+	defaultModelTimeout     = time.Second
+	defaultModelThreshold   = 0.8
+)
+
+// modelMode is how the model service takes part in the decisions.
+type modelMode int
+
+const (
+	// modelModeOff never sends requests to the model service.
+	modelModeOff modelMode = iota
+	// modelModeShadow reports the decisions and compares the predictions
+	// with them in the background, the decisions are never changed.
+	modelModeShadow
+	// modelModeActive asks the model service before the decision, and
+	// follows the prediction if it is probable enough.
+	modelModeActive
+)
+
+var modelModeNames = []string{"off", "shadow", "active"}
+
+func (m modelMode) String() string {
+	if int(m) < len(modelModeNames) {
+		return modelModeNames[m]
+	}
+	return "unknown"
+}
+
+func parseModelMode(s string) (modelMode, error) {
+	for i, name := range modelModeNames {
+		if s == name {
+			return modelMode(i), nil
+		}
+	}
+	return modelModeOff, errors.Errorf("invalid model mode %q", s)
+}
+
+// modelKillSwitchEnv is the environment variable checked at startup, when it
+// is true no request is sent to the model service, whatever the cluster
+// configuration "disable-hot-region-model" is.
+const modelKillSwitchEnv = "PD_DISABLE_HOT_REGION_MODEL"
+
+var modelDisabledByEnv = parseModelKillSwitchEnv(os.Getenv(modelKillSwitchEnv))
+
+// parseModelKillSwitchEnv returns whether the value of modelKillSwitchEnv
+// disables the model service, an invalid value disables it too.
+func parseModelKillSwitchEnv(value string) bool {
+	if value == "" {
+		return false
+	}
+	disabled, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Warnf("invalid %s %q, the model service is disabled", modelKillSwitchEnv, value)
+		return true
+	}
+	return disabled
+}
+
+func init() {
+	if modelDisabledByEnv {
+		hotModelDisabled.Set(1)
+	}
+}
+
+// errModelDisabled is returned for the requests refused by the kill switch.
+var errModelDisabled = errors.New("model service is disabled by the kill switch")
+
+// modelKillSwitch is shared by the clients of a scheduler, so that the
+// requests already queued are refused once it is turned on.
+type modelKillSwitch struct {
+	on int32
+}
+
+func (k *modelKillSwitch) set(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&k.on, v)
+}
+
+func (k *modelKillSwitch) isOn() bool {
+	return modelDisabledByEnv || atomic.LoadInt32(&k.on) != 0
+}
+
+// modelClient sends the requests to the model service, each request is
+// canceled after timeout, or once the scheduler is cleaned up.
+type modelClient struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	client     *http.Client
+	timeout    time.Duration
+	killSwitch *modelKillSwitch
+}
+
+func newModelClient() *modelClient {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &modelClient{
+		ctx:        ctx,
+		cancel:     cancel,
+		client:     &http.Client{},
+		timeout:    defaultModelTimeout,
+		killSwitch: &modelKillSwitch{},
+	}
+}
+
+// modelWorkerPool sends the requests to the model service in the background,
+// so that a slow or unreachable model service never blocks the scheduling.
+// The workers are started by the first job, at most workers jobs are queued
+// and the jobs beyond are dropped.
+type modelWorkerPool struct {
+	sync.Mutex
+	name    string
+	workers int
+	jobs    chan func()
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+func newModelWorkerPool(name string, workers int) *modelWorkerPool {
+	return &modelWorkerPool{name: name, workers: workers}
+}
+
+// setWorkers sets the number of workers, it takes no effect once the workers
+// are started.
+func (p *modelWorkerPool) setWorkers(workers int) {
+	p.Lock()
+	defer p.Unlock()
+	p.workers = workers
+}
+
+// submit queues the job and returns false if it is dropped.
+func (p *modelWorkerPool) submit(job func()) bool {
+	p.Lock()
+	defer p.Unlock()
+	if p.stopped {
+		return false
+	}
+	if p.jobs == nil {
+		p.jobs = make(chan func(), p.workers)
+		p.wg.Add(p.workers)
+		for i := 0; i < p.workers; i++ {
+			go func(jobs <-chan func()) {
+				defer p.wg.Done()
+				for job := range jobs {
+					job()
+				}
+			}(p.jobs)
+		}
+	}
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		schedulerCounter.WithLabelValues(p.name, "model_request_dropped").Inc()
+		return false
+	}
+}
+
+// stop lets the workers exit after the queued jobs are done.
+func (p *modelWorkerPool) stop() {
+	p.Lock()
+	defer p.Unlock()
+	if !p.stopped && p.jobs != nil {
+		close(p.jobs)
+	}
+	p.stopped = true
+}
+
+// wait waits for the workers to exit after stopped.
+func (p *modelWorkerPool) wait() {
+	p.wg.Wait()
+}
+
+// The reasons why a candidate is chosen as the destination store.
+const (
+	candidateFewerRegions = "fewer-regions"
+	candidateLessFlow     = "less-flow"
+	candidateNoHotRegion  = "no-hot-region"
+	// candidateInsufficientMargin means the candidate is not cold enough
+	// compared with the source store.
+	candidateInsufficientMargin = "insufficient-margin"
+)
+
+// fixedFeatures generates a feature vector of a fixed length and order. Each
+// of the effectiveFeatureSlots slots describes a candidate store sorted by ID, the
+// absent candidates are padded with store 0, the candidates beyond the slots
+// are not described. The source store feature comes last.
+func (h *balanceHotRegionsScheduler) fixedFeatures(candidateStoreIDs []uint64, matched map[uint64]string, srcStoreID uint64) []Feature {
+	ids := append([]uint64(nil), candidateStoreIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	slots := h.effectiveFeatureSlots()
+	features := make([]Feature, 0, slots*4+1)
+	for i := 0; i < slots; i++ {
+		var storeID uint64
+		if i < len(ids) {
+			storeID = ids[i]
+		}
+		reason := matched[storeID]
+		features = append(features,
+			hotFeatures.Build(featureCandidateStore, uint64(i), strconv.FormatUint(storeID, 10)),
+			hotFeatures.Build(featureCandidateFewerRegions, uint64(i), strconv.FormatBool(reason == candidateFewerRegions)),
+			hotFeatures.Build(featureCandidateLessFlow, uint64(i), strconv.FormatBool(reason == candidateLessFlow)),
+			hotFeatures.Build(featureCandidateNoHotRegion, uint64(i), strconv.FormatBool(reason == candidateNoHotRegion)),
+		)
+	}
+	return append(features, hotFeatures.Build(featureSrcRegion, 0, strconv.FormatUint(srcStoreID, 10)))
+}
+
+// decisionFeatureNames are the features describing the decision beyond the
+// candidates, in the order they follow the features of the selection.
+var decisionFeatureNames = []string{
+	featureLeaderLabel,
+	featureRelaxed,
+	featureFlowBytesP99,
+	featureCoprocessorFlowBytes,
+	featureGetFlowBytes,
+	featureDestApplyLoad,
+}
+
+// appendDecisionFeatures appends the decision features with the values of
+// the present ones. In the v2 schema each of them has its slot and the
+// absent ones are padded with an empty value, so the vector has the same
+// length and layout for the leader transfers and the peer moves. The absent
+// ones are omitted otherwise.
+func (h *balanceHotRegionsScheduler) appendDecisionFeatures(features []Feature, present map[string]string) []Feature {
+	pad := h.effectiveSchema() == featureSchemaV2
+	for _, name := range decisionFeatureNames {
+		if value, ok := present[name]; ok {
+			features = append(features, hotFeatures.Build(name, 0, value))
+		} else if pad {
+			features = append(features, hotFeatures.Build(name, 0, ""))
+		}
+	}
+	return features
+}
+
+// modelUpdate is a scheduling decision reported to the model service for training.
+type modelUpdate struct {
+	regionID    uint64
+	srcStoreID  uint64
+	destStoreID uint64
+	step        string
+	features    []Feature
+}
+
+type modelUpdateKey struct {
+	regionID    uint64
+	srcStoreID  uint64
+	destStoreID uint64
+	step        string
+}
+
+// pendingModelUpdate is an update waiting to be sent, count records how many
+// identical updates are merged into it.
+type pendingModelUpdate struct {
+	modelUpdate
+	count     int
+	firstSeen time.Time
+}
+
+// modelReporter sends the updates to the model service. When dedup is on,
+// identical updates (same region, source, destination and step) reported
+// within the dedup window, e.g. by both the read and write balance paths,
+// are merged into one update carrying a count. The updates are sent in
+// batches of at most batchSize updates, a batch is sent once it is full or
+// batchDelay after its first update.
+type modelReporter struct {
+	dedup   bool
+	window  time.Duration
+	pending map[modelUpdateKey]*pendingModelUpdate
+	// batch is the updates waiting to be sent together, in the order they
+	// are ready, batchStart is when the first of them is ready.
+	batchSize  int
+	batchDelay time.Duration
+	batch      []*pendingModelUpdate
+	batchStart time.Time
+	send       func(batch []*pendingModelUpdate)
+	// closed makes the last batch sent synchronously without the worker
+	// pool, which is stopped by the cleanup.
+	closed bool
+	// url is the URL of the model service.
+	url    string
+	pool   *modelWorkerPool
+	client *modelClient
+}
+
+func newModelReporter(pool *modelWorkerPool, client *modelClient) *modelReporter {
+	r := &modelReporter{
+		dedup:      true,
+		window:     defaultModelDedupWindow,
+		pending:    make(map[modelUpdateKey]*pendingModelUpdate),
+		batchSize:  defaultModelBatchSize,
+		batchDelay: defaultModelBatchDelay,
+		pool:       pool,
+		client:     client,
+	}
+	r.send = r.sendModelUpdates
+	return r
+}
+
+func (r *modelReporter) report(u modelUpdate, now time.Time) {
+	r.flush(now, false)
+	if !r.dedup {
+		r.enqueue(now, &pendingModelUpdate{modelUpdate: u, count: 1, firstSeen: now})
+		return
+	}
+	key := modelUpdateKey{regionID: u.regionID, srcStoreID: u.srcStoreID, destStoreID: u.destStoreID, step: u.step}
+	if p, ok := r.pending[key]; ok {
+		p.count++
+		p.features = u.features
+		return
+	}
+	r.pending[key] = &pendingModelUpdate{modelUpdate: u, count: 1, firstSeen: now}
+}
+
+// flush moves the pending updates whose dedup window has passed to the batch,
+// and sends the batch if it is due. Everything is sent if force is set.
+func (r *modelReporter) flush(now time.Time, force bool) {
+	var ready []*pendingModelUpdate
+	for key, p := range r.pending {
+		if force || now.Sub(p.firstSeen) >= r.window {
+			ready = append(ready, p)
+			delete(r.pending, key)
+		}
+	}
+	// The updates are batched in the order they are first seen.
+	sort.Slice(ready, func(i, j int) bool {
+		if !ready[i].firstSeen.Equal(ready[j].firstSeen) {
+			return ready[i].firstSeen.Before(ready[j].firstSeen)
+		}
+		return ready[i].regionID < ready[j].regionID
+	})
+	for _, p := range ready {
+		r.enqueue(now, p)
+	}
+	if len(r.batch) != 0 && (force || now.Sub(r.batchStart) >= r.batchDelay) {
+		r.sendBatch()
+	}
+}
+
+// enqueue appends the update to the batch, and sends the batch if it is full.
+func (r *modelReporter) enqueue(now time.Time, p *pendingModelUpdate) {
+	if len(r.batch) == 0 {
+		r.batchStart = now
+	}
+	r.batch = append(r.batch, p)
+	if len(r.batch) >= r.batchSize {
+		r.sendBatch()
+	}
+}
+
+func (r *modelReporter) sendBatch() {
+	batch := r.batch
+	r.batch = nil
+	r.send(batch)
+}
+
+// close sends all the updates not sent yet, it is called by the cleanup.
+func (r *modelReporter) close(now time.Time) {
+	r.closed = true
+	r.flush(now, true)
+}
+
+// encodeModelUpdates encodes the updates as
+// `{"updates":[[step, features, count], ...]}`, each update keeps its own step.
+func encodeModelUpdates(batch []*pendingModelUpdate) (string, error) {
+	updates := make([]interface{}, 0, len(batch))
+	for _, u := range batch {
+		updates = append(updates, []interface{}{u.step, u.features, u.count})
+	}
+	b, err := json.Marshal(updates)
+	if err != nil {
+		return "", err
+	}
+	return "{\"updates\":" + string(b) + "}", nil
+}
+
+func (r *modelReporter) sendModelUpdates(batch []*pendingModelUpdate) {
+	str, err := encodeModelUpdates(batch)
+	if err != nil {
+		log.Errorf("failed to encode model updates: %v", err)
+		return
+	}
+	// PUT model service
+	reqURL := r.url
+	if reqURL == "" {
+		return
+	}
+	hotModelBatchSize.Observe(float64(len(batch)))
+	put := func(client *modelClient) {
+		start := time.Now()
+		httpClient(client, reqURL, "PUT", str)
+		hotModelFlushLatency.Observe(time.Since(start).Seconds())
+	}
+	if r.closed {
+		// The last batch is sent before the cleanup cancels the client and
+		// stops the pool, it is bounded by the timeout of the request.
+		put(r.client)
+		return
+	}
+	client := r.client
+	r.pool.submit(func() {
+		put(client)
+	})
+}
+
+// parseModelEndpoint checks the model service endpoint passed at
+// registration, which is either empty or an absolute URL.
+func parseModelEndpoint(endpoint string) (string, error) {
+	if endpoint == "" {
+		return "", nil
+	}
+	if u, err := url.Parse(endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+		return "", errors.Errorf("invalid model endpoint %q", endpoint)
+	}
+	return endpoint, nil
+}
+
+// updateModelURL follows the model service URL configured in the cluster
+// unless the endpoint is passed at registration, the change takes effect
+// from the current dispatch. The URL is empty while the kill switch is on,
+// which stops all the interaction with the model service.
+func (h *balanceHotRegionsScheduler) updateModelURL(cluster schedule.Cluster) {
+	h.setModelDisabled(modelDisabledByEnv || cluster.IsHotRegionModelDisabled())
+	reqURL, reason := cluster.GetHotRegionModelURL(), "follow the cluster configuration hot-region-model-url"
+	if h.modelEndpoint != nil {
+		reqURL, reason = *h.modelEndpoint, "follow the argument model-endpoint"
+	}
+	if h.modelDisabled {
+		reqURL, reason = "", "disabled by the kill switch"
+	}
+	if reqURL == h.modelURL {
+		return
+	}
+	if err := h.setTunable("model-url", reqURL, core.HotChangeSourceInternal, reason); err != nil {
+		log.Errorf("[%s] failed to change the model service: %v", h.GetName(), err)
+		return
+	}
+	h.reporter.url = reqURL
+	// The predictions of the former model service are not followed.
+	h.predictions.clear()
+	h.schemaDue = true
+}
+
+// setModelDisabled turns the kill switch of the model service on or off.
+func (h *balanceHotRegionsScheduler) setModelDisabled(disabled bool) {
+	if disabled == h.modelDisabled {
+		return
+	}
+	if err := h.setTunable("model-disabled", disabled, core.HotChangeSourceInternal, "follow the kill switch"); err != nil {
+		log.Errorf("[%s] failed to turn the kill switch: %v", h.GetName(), err)
+		return
+	}
+	h.client.killSwitch.set(disabled)
+	if disabled {
+		log.Warnf("[%s] the model service is disabled by the kill switch", h.GetName())
+		hotModelDisabled.Set(1)
+	} else {
+		log.Infof("[%s] the model service is enabled", h.GetName())
+		hotModelDisabled.Set(0)
+	}
+}
+
+// modelRequestBody encodes the features of a decision to the body of the
+// prediction request.
+func modelRequestBody(ms []Feature) string {
+	b, err := json.Marshal(ms)
+	if err != nil {
+		log.Println(err)
+	}
+	return "{\"features\": [" + string(b) + "]}"
+}
+
+// observePrediction compares the prediction with the heuristic decision, the
+// model's answer is counted as "model_hit" or "model_miss" and by the mode,
+// and the rank of its suggestion in the ranked candidates is observed.
+func observePrediction(name string, mode modelMode, prediction *Prediction, srcStoreID, destStoreID uint64, ranked []uint64) {
+	result := "miss"
+	if prediction.SrcStoreID == srcStoreID && prediction.DestStoreID == destStoreID {
+		result = "hit"
+	}
+	log.Infof("[HOT] suggest step: store %d to store %d, probability: %.15f, actual step: store %d to store %d, %s",
+		prediction.SrcStoreID, prediction.DestStoreID, prediction.Probability, srcStoreID, destStoreID, result)
+	schedulerCounter.WithLabelValues(name, "model_"+result).Inc()
+	hotModelPredictionCounter.WithLabelValues(mode.String(), result).Inc()
+	hotModelSuggestionRank.Observe(float64(modelSuggestionRank(ranked, prediction.DestStoreID)))
+}
+
+// predictDestStore follows the prediction of the model service for the
+// destination store in the active mode. The dispatch never waits for the
+// model service: the prediction of a region is asked in the background the
+// first time, and the heuristic destination is kept until it is answered,
+// see hotPredictionCache. The heuristic destination is also kept unless the
+// prediction is at least modelThreshold probable, for the same source store
+// and one of the candidates. The features are those of the heuristic
+// destination. The prediction is returned as well, nil if there is none.
+func (h *balanceHotRegionsScheduler) predictDestStore(regionID uint64, ms []Feature, srcStoreID, destStoreID uint64, candidateStoreIDs, ranked []uint64) (uint64, *Prediction) {
+	if h.modelMode != modelModeActive || ms == nil || h.hypothetical() || h.modelURL == "" {
+		return destStoreID, nil
+	}
+	// Nothing to predict without a candidate.
+	if destStoreID == 0 || len(candidateStoreIDs) == 0 {
+		return destStoreID, nil
+	}
+	key := predictionKey{regionID: regionID, srcStoreID: srcStoreID}
+	prediction, ask := h.predictions.get(key, h.now())
+	if ask {
+		h.askPrediction(key, ms, destStoreID, h.now())
+		return destStoreID, nil
+	}
+	if prediction == nil {
+		return destStoreID, nil
+	}
+	observePrediction(h.GetName(), modelModeActive, prediction, srcStoreID, destStoreID, ranked)
+	if prediction.Probability < h.modelThreshold || prediction.SrcStoreID != srcStoreID || !containsStore(candidateStoreIDs, prediction.DestStoreID) {
+		return destStoreID, prediction
+	}
+	if prediction.DestStoreID != destStoreID {
+		schedulerCounter.WithLabelValues(h.GetName(), "model_override").Inc()
+		log.Infof("[%s] follow the model to move hot region %d from store %d to store %d instead of store %d",
+			h.GetName(), regionID, srcStoreID, prediction.DestStoreID, destStoreID)
+	}
+	return prediction.DestStoreID, prediction
+}
+
+func containsStore(storeIDs []uint64, storeID uint64) bool {
+	for _, id := range storeIDs {
+		if id == storeID {
+			return true
+		}
+	}
+	return false
+}
+
+// modelDecision is a decision of the scheduler reported to the model
+// service, the features are those of the destination.
+type modelDecision struct {
+	regionID    uint64
+	step        string
+	features    []Feature
+	srcStoreID  uint64
+	destStoreID uint64
+	ranked      []uint64
+}
+
+// modelHook receives the decisions of the scheduler once the operators are
+// created, so that the selection never talks to the model service itself.
+type modelHook interface {
+	onDecision(d modelDecision)
+}
+
+// noopModelHook drops the decisions, it is used when there is no model
+// service to report to.
+type noopModelHook struct{}
+
+func (noopModelHook) onDecision(modelDecision) {}
+
+// serviceModelHook reports the decisions to the model service by postJSON.
+type serviceModelHook struct {
+	h *balanceHotRegionsScheduler
+}
+
+func (s serviceModelHook) onDecision(d modelDecision) {
+	s.h.postJSON(d.regionID, d.step, d.features, d.srcStoreID, d.destStoreID, d.ranked)
+}
+
+// modelHook returns the hook of the current decision. The injected hook is
+// used if any, otherwise the decisions are reported only when there is a
+// model service. The hypothetical decisions are never reported.
+func (h *balanceHotRegionsScheduler) modelHook() modelHook {
+	if h.hypothetical() {
+		return noopModelHook{}
+	}
+	if h.hook != nil {
+		return h.hook
+	}
+	if h.modelURL == "" || h.modelMode == modelModeOff {
+		return noopModelHook{}
+	}
+	return serviceModelHook{h: h}
+}
+
+// reportDecision passes the decision of the operator just created, with the
+// features and the ranked candidates recorded by the selection, to the
+// model hook.
+func (h *balanceHotRegionsScheduler) reportDecision(regionID uint64, step schedule.OperatorStep, srcStoreID, destStoreID uint64) {
+	h.modelHook().onDecision(modelDecision{
+		regionID:    regionID,
+		step:        step.String(),
+		features:    h.features,
+		srcStoreID:  srcStoreID,
+		destStoreID: destStoreID,
+		ranked:      h.rankedCandidates,
+	})
+}
+
+// postJSON reports the decision to the model service in the background. In
+// the shadow mode, the decision is also sent for a prediction, which is
+// compared with the decision by observePrediction.
+func (h *balanceHotRegionsScheduler) postJSON(regionID uint64, s string, ms []Feature, srcStoreID, destStoreID uint64, ranked []uint64) {
+	// Never report the hypothetical decisions, nor without a model service.
+	if s == "" || ms == nil || h.hypothetical() || h.modelURL == "" || h.modelMode == modelModeOff {
+		return
+	}
+	update := modelUpdate{
+		regionID:    regionID,
+		srcStoreID:  srcStoreID,
+		destStoreID: destStoreID,
+		step:        s,
+		features:    ms,
+	}
+	if h.modelMode == modelModeActive {
+		// The prediction is asked before the decision by predictDestStore.
+		h.reporter.report(update, time.Now())
+		return
+	}
+	if !h.breaker.allow(time.Now()) {
+		schedulerCounter.WithLabelValues(h.GetName(), "model_circuit_open").Inc()
+		return
+	}
+	span, finish := h.startSpan(hotSpanModelRequest)
+	defer finish()
+	setSpanTag(span, hotSpanTagRegion, regionID)
+	setSpanTag(span, hotSpanTagSrcStore, srcStoreID)
+	setSpanTag(span, hotSpanTagDestStore, destStoreID)
+	h.reporter.report(update, time.Now())
+
+	// POST model
+	gstr := modelRequestBody(ms)
+	name, reqURL, breaker, client := h.GetName(), h.modelURL, h.breaker, h.client
+	queued := h.pool.submit(func() {
+		prediction, err := httpClient(client, reqURL, "POST", gstr)
+		if err == errModelDisabled {
+			return
+		}
+		breaker.record(time.Now(), err == nil)
+		if prediction != nil {
+			observePrediction(name, modelModeShadow, prediction, srcStoreID, destStoreID, ranked)
+		}
+	})
+	if queued {
+		setSpanTag(span, hotSpanTagOutcome, "queued")
+	} else {
+		breaker.cancelProbe()
+		setSpanTag(span, hotSpanTagOutcome, "dropped")
+	}
+}
+
+// httpClient sends the request to the model service and returns the
+// prediction in the response, which is nil if there is none or it is
+// malformed. It returns an error if the request fails, times out or the
+// model service responds with a server error.
+func httpClient(client *modelClient, reqURL, method, jsonStr string) (*Prediction, error) {
+	body, err := modelRequest(client, reqURL, method, jsonStr)
+	if err != nil {
+		return nil, err
+	}
+	// Only the answers to the decisions carry predictions.
+	if method != "POST" {
+		return nil, nil
+	}
+	prediction, err := parsePrediction(body)
+	if err != nil {
+		hotModelParseErrorCounter.Inc()
+		log.Warnf("[HOT] failed to parse the model prediction: %v", err)
+		return nil, nil
+	}
+	return prediction, nil
+}
+
+// modelRequest sends the request to the model service and returns the body
+// of the response. All the requests to the model service go through it, so
+// it refuses them with errModelDisabled while the kill switch is on.
+func modelRequest(client *modelClient, reqURL, method, jsonStr string) ([]byte, error) {
+	if client.killSwitch.isOn() {
+		hotModelRefusedCounter.Inc()
+		return nil, errModelDisabled
+	}
+	req, err := http.NewRequest(method, reqURL, strings.NewReader(jsonStr))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// The context also bounds reading the response body.
+	ctx, cancel := context.WithTimeout(client.ctx, client.timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.client.Do(req)
+
+	if resp == nil || err != nil {
+		log.Println("[HOT] http request error or resp is nil, ", err)
+		return nil, errors.Errorf("model service request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		log.Println("[HOT] model service responds ", resp.Status)
+		return nil, errors.Errorf("model service responds %s", resp.Status)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	log.Debugf("[HOT] model service responds %s to %s %s", resp.Status, method, reqURL)
+	return body, nil
+}
+
+// predictionResponse is the response of the model service. Each prediction
+// maps the class keys, e.g. "transfer leader from store 7 to store 2", to
+// their probabilities.
+type predictionResponse struct {
+	Predictions []map[string]float64 `json:"predictions"`
+}
+
+// predictionKeyPattern is the schema of the class keys, which are the steps
+// reported to the model service, i.e. the strings of schedule.TransferLeader.
+// Only the leader transfers are reported, so the predictions are followed by
+// the leader transfers only.
+var predictionKeyPattern = regexp.MustCompile(`^transfer leader from store (\d+) to store (\d+)$`)
+
+// parsePredictionKey returns the source and destination stores of the class
+// key, ok is false if the key does not follow the schema.
+func parsePredictionKey(key string) (srcStoreID, destStoreID uint64, ok bool) {
+	matches := predictionKeyPattern.FindStringSubmatch(key)
+	if matches == nil {
+		return 0, 0, false
+	}
+	srcStoreID, err := strconv.ParseUint(matches[1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	destStoreID, err = strconv.ParseUint(matches[2], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return srcStoreID, destStoreID, true
+}
+
+// Prediction is the most probable step suggested by the model service.
+type Prediction struct {
+	SrcStoreID  uint64
+	DestStoreID uint64
+	Probability float64
+}
+
+// parsePrediction returns the most probable class in the first prediction of
+// the response. The malformed class keys are skipped.
+func parsePrediction(body []byte) (*Prediction, error) {
+	var resp predictionResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(resp.Predictions) == 0 || len(resp.Predictions[0]) == 0 {
+		return nil, errors.New("no prediction in the response")
+	}
+	var (
+		prediction *Prediction
+		key        string
+	)
+	for k, p := range resp.Predictions[0] {
+		srcStoreID, destStoreID, ok := parsePredictionKey(k)
+		if !ok {
+			log.Debugf("[HOT] skip the unexpected prediction class %q", k)
+			continue
+		}
+		// Break the ties by the key to be deterministic.
+		if prediction == nil || p > prediction.Probability || (p == prediction.Probability && k < key) {
+			prediction = &Prediction{SrcStoreID: srcStoreID, DestStoreID: destStoreID, Probability: p}
+			key = k
+		}
+	}
+	if prediction == nil {
+		return nil, errors.New("no expected prediction class in the response")
+	}
+	return prediction, nil
+}
+
+// GetModelConfig returns how the scheduler uses the model service.
+func (h *balanceHotRegionsScheduler) GetModelConfig() core.HotModelConfig {
+	h.RLock()
+	defer h.RUnlock()
+	return core.HotModelConfig{Mode: h.modelMode.String(), Threshold: h.modelThreshold}
+}
+
+// SetModelConfig changes how the scheduler uses the model service from the
+// next dispatch. The empty mode or zero threshold is not changed.
+func (h *balanceHotRegionsScheduler) SetModelConfig(cfg core.HotModelConfig) error {
+	if err := h.setModelConfig(cfg); err != nil {
+		return err
+	}
+	h.persistConfig()
+	return nil
+}
+
+func (h *balanceHotRegionsScheduler) setModelConfig(cfg core.HotModelConfig) error {
+	h.Lock()
+	defer h.Unlock()
+	mode, threshold := h.modelMode, h.modelThreshold
+	if cfg.Mode != "" {
+		var err error
+		if mode, err = parseModelMode(cfg.Mode); err != nil {
+			return err
+		}
+	}
+	if cfg.Threshold != 0 {
+		if !(cfg.Threshold > 0 && cfg.Threshold <= 1) {
+			return errors.Errorf("invalid model threshold %v, it must be in (0, 1]", cfg.Threshold)
+		}
+		threshold = cfg.Threshold
+	}
+	if err := h.setTunable("model-mode", mode, core.HotChangeSourceUser, hotConfigUpdateReason); err != nil {
+		return err
+	}
+	return h.setTunable("model-threshold", threshold, core.HotChangeSourceUser, hotConfigUpdateReason)
+}
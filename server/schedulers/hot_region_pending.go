@@ -0,0 +1,179 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+)
+
+// pendingInfluence is the flow an operator of the scheduler moves, which is
+// not reflected in the statistics until the operator completes.
+type pendingInfluence struct {
+	op                      *schedule.Operator
+	typ                     BalanceType
+	srcStoreID, destStoreID uint64
+	flowBytes, flowKeys     uint64
+}
+
+// addPendingInfluence tracks the flow of the region moved by the operator
+// from the source store to the destination store, and starts the cooldown of
+// the region.
+func (h *balanceHotRegionsScheduler) addPendingInfluence(op *schedule.Operator, typ BalanceType, storesStat core.StoreHotRegionsStat, srcStoreID, destStoreID uint64) {
+	// The operators of the dry run are never running.
+	if h.preview {
+		return
+	}
+	h.markMoved(op.RegionID())
+	rs := hotRegionStat(op.RegionID(), storesStat, srcStoreID)
+	if rs == nil {
+		return
+	}
+	h.pendings[op.RegionID()] = &pendingInfluence{
+		op:          op,
+		typ:         typ,
+		srcStoreID:  srcStoreID,
+		destStoreID: destStoreID,
+		flowBytes:   rs.FlowBytes,
+		flowKeys:    rs.FlowKeys,
+	}
+}
+
+// prunePendingInfluence drops the influence of the operators which are no
+// longer running, because they are finished, timed out, replaced or never
+// added to the operator controller.
+func (h *balanceHotRegionsScheduler) prunePendingInfluence() {
+	for regionID, p := range h.pendings {
+		if p.op.IsFinish() || h.opController.GetOperator(regionID) != p.op {
+			delete(h.pendings, regionID)
+		}
+	}
+}
+
+// pendingFlowBytes returns the flow bytes moving in (positive) and out
+// (negative) of the stores by the running operators of the balance type,
+// sorted by the store ID, nil if there is none.
+func (h *balanceHotRegionsScheduler) pendingFlowBytes(typ BalanceType) []core.HotStorePendingFlow {
+	flows := make(map[uint64]int64)
+	for _, p := range h.pendings {
+		if p.typ != typ {
+			continue
+		}
+		flows[p.srcStoreID] -= int64(p.flowBytes)
+		flows[p.destStoreID] += int64(p.flowBytes)
+	}
+	if len(flows) == 0 {
+		return nil
+	}
+	status := make([]core.HotStorePendingFlow, 0, len(flows))
+	for storeID, flowBytes := range flows {
+		status = append(status, core.HotStorePendingFlow{StoreID: storeID, FlowBytes: flowBytes})
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].StoreID < status[j].StoreID })
+	return status
+}
+
+// withPendingInfluence returns the statistics with the flow and the hot
+// region of the running operators of the current balance type moved from the
+// source stores to the destination stores, so the same stores are not picked
+// again before the moves complete. Only the stores in the statistics are
+// adjusted, the stores without hot regions are not made up. The statistics
+// are copied only if there is any influence.
+func (h *balanceHotRegionsScheduler) withPendingInfluence(storesStat core.StoreHotRegionsStat) core.StoreHotRegionsStat {
+	var adjusted core.StoreHotRegionsStat
+	for regionID, p := range h.pendings {
+		if p.typ != h.balanceType {
+			continue
+		}
+		if adjusted == nil {
+			adjusted = make(core.StoreHotRegionsStat, len(storesStat))
+			for storeID, stat := range storesStat {
+				clone := *stat
+				adjusted[storeID] = &clone
+			}
+		}
+		if src, ok := adjusted[p.srcStoreID]; ok {
+			src.TotalFlowBytes -= minUint64(src.TotalFlowBytes, p.flowBytes)
+			src.TotalFlowKeys -= minUint64(src.TotalFlowKeys, p.flowKeys)
+			if regionsStat, removed := withoutRegionStat(src.RegionsStat, regionID); removed {
+				src.RegionsStat = regionsStat
+				src.RegionsCount--
+			}
+		}
+		if dest, ok := adjusted[p.destStoreID]; ok {
+			dest.TotalFlowBytes += p.flowBytes
+			dest.TotalFlowKeys += p.flowKeys
+			// The slice is shared with the statistics, so it is copied by
+			// the full slice expression.
+			dest.RegionsStat = append(dest.RegionsStat[:len(dest.RegionsStat):len(dest.RegionsStat)], core.RegionStat{
+				RegionID:  regionID,
+				StoreID:   p.destStoreID,
+				FlowBytes: p.flowBytes,
+				FlowKeys:  p.flowKeys,
+			})
+			dest.RegionsCount++
+		}
+	}
+	if adjusted == nil {
+		return storesStat
+	}
+	return adjusted
+}
+
+// withoutRegionStat returns a copy of the stats without the region, and
+// whether the region is in the stats.
+func withoutRegionStat(regionsStat core.RegionsStat, regionID uint64) (core.RegionsStat, bool) {
+	for i := range regionsStat {
+		if regionsStat[i].RegionID == regionID {
+			stats := make(core.RegionsStat, 0, len(regionsStat)-1)
+			stats = append(stats, regionsStat[:i]...)
+			return append(stats, regionsStat[i+1:]...), true
+		}
+	}
+	return regionsStat, false
+}
+
+// isPending returns true if the region is moved by a running operator of the
+// scheduler. Such a region is counted in the destination store by
+// withPendingInfluence, so it is never moved again from there.
+func (h *balanceHotRegionsScheduler) isPending(regionID uint64) bool {
+	_, ok := h.pendings[regionID]
+	return ok
+}
+
+// markMoved starts the cooldown of the region, nothing is recorded if there
+// is no cooldown.
+func (h *balanceHotRegionsScheduler) markMoved(regionID uint64) {
+	if h.regionCooldown > 0 {
+		h.movedRegions[regionID] = h.now()
+	}
+}
+
+// inCooldown returns true if the region is moved within the cooldown.
+func (h *balanceHotRegionsScheduler) inCooldown(regionID uint64) bool {
+	movedAt, ok := h.movedRegions[regionID]
+	return ok && h.now().Sub(movedAt) < h.regionCooldown
+}
+
+// pruneMovedRegions drops the regions whose cooldown has elapsed.
+func (h *balanceHotRegionsScheduler) pruneMovedRegions(now time.Time) {
+	for regionID, movedAt := range h.movedRegions {
+		if now.Sub(movedAt) >= h.regionCooldown {
+			delete(h.movedRegions, regionID)
+		}
+	}
+}
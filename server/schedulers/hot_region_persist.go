@@ -0,0 +1,264 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// persistedConfigReason is the reason of the changes restoring the persisted
+// config.
+const persistedConfigReason = "restore the persisted config"
+
+// hotRegionPersistentConfig is the config of the scheduler persisted to the
+// storage, so that the changes made at runtime survive the PD leader
+// failover. It is stored to "scheduler/{type}/config" of the cluster, i.e.
+// "/pd/{cluster-id}/scheduler/hot-region/config" for hot-region.
+type hotRegionPersistentConfig struct {
+	limit          uint64
+	maxLimit       uint64
+	scheduleFactor float64
+	limitFactor    float64
+	modelMode      modelMode
+	modelThreshold float64
+	// modelEndpoint is only persisted if it overrides the cluster
+	// configuration, the same for minRegionFlowBytes.
+	modelEndpoint         string
+	hasModelEndpoint      bool
+	minRegionFlowBytes    uint64
+	hasMinRegionFlowBytes bool
+	keysWeight            float64
+	retryLimit            int
+	keyRange              hotKeyRange
+	dryRun                bool
+}
+
+// hotRegionPersistentConfigJSON is the encoding of hotRegionPersistentConfig.
+type hotRegionPersistentConfigJSON struct {
+	Limit              uint64  `json:"limit"`
+	MaxLimit           uint64  `json:"max-limit"`
+	ScheduleFactor     float64 `json:"schedule-factor"`
+	LimitFactor        float64 `json:"limit-factor"`
+	ModelMode          string  `json:"model-mode"`
+	ModelThreshold     float64 `json:"model-threshold"`
+	ModelEndpoint      *string `json:"model-endpoint,omitempty"`
+	MinRegionFlowBytes *uint64 `json:"min-region-flow-bytes,omitempty"`
+	KeysWeight         float64 `json:"keys-weight"`
+	RetryLimit         int     `json:"retry-limit"`
+	StartKey           string  `json:"start-key"`
+	EndKey             string  `json:"end-key"`
+	DryRun             bool    `json:"dry-run"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (cfg hotRegionPersistentConfig) MarshalJSON() ([]byte, error) {
+	data := hotRegionPersistentConfigJSON{
+		Limit:          cfg.limit,
+		MaxLimit:       cfg.maxLimit,
+		ScheduleFactor: cfg.scheduleFactor,
+		LimitFactor:    cfg.limitFactor,
+		ModelMode:      cfg.modelMode.String(),
+		ModelThreshold: cfg.modelThreshold,
+		KeysWeight:     cfg.keysWeight,
+		RetryLimit:     cfg.retryLimit,
+		StartKey:       hex.EncodeToString(cfg.keyRange.startKey),
+		EndKey:         hex.EncodeToString(cfg.keyRange.endKey),
+		DryRun:         cfg.dryRun,
+	}
+	if cfg.hasModelEndpoint {
+		data.ModelEndpoint = &cfg.modelEndpoint
+	}
+	if cfg.hasMinRegionFlowBytes {
+		data.MinRegionFlowBytes = &cfg.minRegionFlowBytes
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The config is validated as the
+// arguments passed at registration, cfg is not changed if it is invalid.
+func (cfg *hotRegionPersistentConfig) UnmarshalJSON(b []byte) error {
+	var data hotRegionPersistentConfigJSON
+	if err := json.Unmarshal(b, &data); err != nil {
+		return errors.WithStack(err)
+	}
+	if data.Limit == 0 {
+		return errors.New("invalid hot region scheduler limit 0")
+	}
+	if data.ScheduleFactor == 0 || data.LimitFactor == 0 {
+		return errors.New("invalid factor 0, it must be in (0, 1]")
+	}
+	if err := validateHotRegionFactors(core.HotRegionFactors{ScheduleFactor: data.ScheduleFactor, LimitFactor: data.LimitFactor}); err != nil {
+		return err
+	}
+	mode, err := parseModelMode(data.ModelMode)
+	if err != nil {
+		return err
+	}
+	if !(data.ModelThreshold > 0 && data.ModelThreshold <= 1) {
+		return errors.Errorf("invalid model threshold %v, it must be in (0, 1]", data.ModelThreshold)
+	}
+	if err = validateKeysWeight(data.KeysWeight); err != nil {
+		return err
+	}
+	if err = validateRetryLimit(data.RetryLimit); err != nil {
+		return err
+	}
+	var keyRange hotKeyRange
+	if keyRange.startKey, err = parseHexKey("start-key", data.StartKey); err != nil {
+		return err
+	}
+	if keyRange.endKey, err = parseHexKey("end-key", data.EndKey); err != nil {
+		return err
+	}
+	if err = keyRange.validate(); err != nil {
+		return err
+	}
+	decoded := hotRegionPersistentConfig{
+		limit:          data.Limit,
+		maxLimit:       data.MaxLimit,
+		scheduleFactor: data.ScheduleFactor,
+		limitFactor:    data.LimitFactor,
+		modelMode:      mode,
+		modelThreshold: data.ModelThreshold,
+		keysWeight:     data.KeysWeight,
+		retryLimit:     data.RetryLimit,
+		keyRange:       keyRange,
+		dryRun:         data.DryRun,
+	}
+	if data.ModelEndpoint != nil {
+		if decoded.modelEndpoint, err = parseModelEndpoint(*data.ModelEndpoint); err != nil {
+			return err
+		}
+		decoded.hasModelEndpoint = true
+	}
+	if data.MinRegionFlowBytes != nil {
+		decoded.minRegionFlowBytes, decoded.hasMinRegionFlowBytes = *data.MinRegionFlowBytes, true
+	}
+	*cfg = decoded
+	return nil
+}
+
+// persistentConfig returns the config to persist, the caller must hold the
+// lock.
+func (h *balanceHotRegionsScheduler) persistentConfig() hotRegionPersistentConfig {
+	cfg := hotRegionPersistentConfig{
+		limit:                 h.baseLimit,
+		maxLimit:              h.maxLimit,
+		scheduleFactor:        h.scheduleFactor,
+		limitFactor:           h.limitFactor,
+		modelMode:             h.modelMode,
+		modelThreshold:        h.modelThreshold,
+		hasModelEndpoint:      h.modelEndpoint != nil,
+		hasMinRegionFlowBytes: h.minRegionFlowBytesOverride != nil,
+		keysWeight:            h.keysWeight,
+		retryLimit:            h.retryLimit,
+		keyRange:              h.keyRange,
+		dryRun:                h.dryRun,
+	}
+	if h.modelEndpoint != nil {
+		cfg.modelEndpoint = *h.modelEndpoint
+	}
+	if h.minRegionFlowBytesOverride != nil {
+		cfg.minRegionFlowBytes = *h.minRegionFlowBytesOverride
+	}
+	return cfg
+}
+
+// applyPersistentConfig restores the persisted config, the caller must hold
+// the lock. The limits adjusted at runtime restart from the persisted limit.
+func (h *balanceHotRegionsScheduler) applyPersistentConfig(cfg hotRegionPersistentConfig) error {
+	type tunableValue struct {
+		name  string
+		value interface{}
+	}
+	var (
+		modelEndpoint      *string
+		minRegionFlowBytes *uint64
+	)
+	if cfg.hasModelEndpoint {
+		endpoint := cfg.modelEndpoint
+		modelEndpoint = &endpoint
+	}
+	values := []tunableValue{
+		{"limit", cfg.limit},
+		{"read-limit", cfg.limit},
+		{"write-limit", cfg.limit},
+		{"max-limit", cfg.maxLimit},
+		{"schedule-factor", cfg.scheduleFactor},
+		{"limit-factor", cfg.limitFactor},
+		{"model-mode", cfg.modelMode},
+		{"model-threshold", cfg.modelThreshold},
+		{"model-endpoint", modelEndpoint},
+		{"keys-weight", cfg.keysWeight},
+		{"retry-limit", cfg.retryLimit},
+		{"key-range", cfg.keyRange},
+		{"dry-run", cfg.dryRun},
+	}
+	if cfg.hasMinRegionFlowBytes {
+		flowBytes := cfg.minRegionFlowBytes
+		minRegionFlowBytes = &flowBytes
+		values = append(values, tunableValue{"min-region-flow-bytes", flowBytes})
+	}
+	values = append(values, tunableValue{"min-region-flow-bytes-override", minRegionFlowBytes})
+	for _, v := range values {
+		if err := h.setTunable(v.name, v.value, core.HotChangeSourceInternal, persistedConfigReason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetConfigStorage restores the config persisted in the storage if any, which
+// takes precedence over the arguments passed at registration, and persists
+// the config on every change from now on. Nothing is changed if the
+// persisted config can't be loaded, so that it is never overwritten.
+func (h *balanceHotRegionsScheduler) SetConfigStorage(storage schedule.ConfigStorage) error {
+	var cfg hotRegionPersistentConfig
+	ok, err := storage.LoadSchedulerConfig(h.GetType(), &cfg)
+	if err != nil {
+		return err
+	}
+	h.Lock()
+	defer h.Unlock()
+	if ok {
+		if err := h.applyPersistentConfig(cfg); err != nil {
+			return err
+		}
+		log.Infof("[%s] restore the persisted config", h.GetName())
+	}
+	h.configStorage = storage
+	return nil
+}
+
+// persistConfig saves the config to the storage if there is one. A failure
+// is logged, the config in memory is changed anyway.
+func (h *balanceHotRegionsScheduler) persistConfig() {
+	h.persistMu.Lock()
+	defer h.persistMu.Unlock()
+	h.RLock()
+	storage, cfg := h.configStorage, h.persistentConfig()
+	h.RUnlock()
+	if storage == nil {
+		return
+	}
+	if err := storage.SaveSchedulerConfig(h.GetType(), cfg); err != nil {
+		log.Errorf("[%s] failed to persist the config: %v", h.GetName(), err)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultModelPredictionTTL is how long a prediction asked for a hot region
+// is used by the active mode before it is asked again.
+const defaultModelPredictionTTL = 30 * time.Second
+
+// predictionKey identifies the predictions by the region and the store it is
+// moved from.
+type predictionKey struct {
+	regionID   uint64
+	srcStoreID uint64
+}
+
+// cachedPrediction is a prediction asked at askedAt, prediction is nil until
+// the model service answers, or if it doesn't.
+type cachedPrediction struct {
+	askedAt    time.Time
+	prediction *Prediction
+}
+
+// hotPredictionCache keeps the predictions of the active mode. They are
+// asked by the workers, so the dispatch never waits for the model service:
+// the first dispatch considering a region asks the prediction and keeps the
+// heuristic destination, the later ones follow the answer until it expires.
+type hotPredictionCache struct {
+	sync.Mutex
+	ttl     time.Duration
+	entries map[predictionKey]*cachedPrediction
+}
+
+func newHotPredictionCache(ttl time.Duration) *hotPredictionCache {
+	return &hotPredictionCache{ttl: ttl, entries: make(map[predictionKey]*cachedPrediction)}
+}
+
+// get returns the prediction of the region, and whether it should be asked
+// because there is none or it is expired.
+func (c *hotPredictionCache) get(key predictionKey, now time.Time) (*Prediction, bool) {
+	c.Lock()
+	defer c.Unlock()
+	e, ok := c.entries[key]
+	if !ok || now.Sub(e.askedAt) >= c.ttl {
+		return nil, true
+	}
+	return e.prediction, false
+}
+
+// ask records the prediction of the region is asked at now, so it is not
+// asked again before it expires.
+func (c *hotPredictionCache) ask(key predictionKey, now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	c.entries[key] = &cachedPrediction{askedAt: now}
+}
+
+// answer fills in the prediction asked at askedAt, unless it is asked again
+// or dropped since then.
+func (c *hotPredictionCache) answer(key predictionKey, askedAt time.Time, prediction *Prediction) {
+	c.Lock()
+	defer c.Unlock()
+	if e, ok := c.entries[key]; ok && e.askedAt.Equal(askedAt) {
+		e.prediction = prediction
+	}
+}
+
+func (c *hotPredictionCache) forget(key predictionKey) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.entries, key)
+}
+
+// prune drops the expired predictions.
+func (c *hotPredictionCache) prune(now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	for key, e := range c.entries {
+		if now.Sub(e.askedAt) >= c.ttl {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// clear drops all the predictions, e.g. when the model service is changed.
+func (c *hotPredictionCache) clear() {
+	c.Lock()
+	defer c.Unlock()
+	c.entries = make(map[predictionKey]*cachedPrediction)
+}
+
+func (c *hotPredictionCache) len() int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.entries)
+}
+
+// askPrediction asks the model service for the prediction of the region in
+// the background, the answer is used by the later dispatches.
+func (h *balanceHotRegionsScheduler) askPrediction(key predictionKey, ms []Feature, destStoreID uint64, now time.Time) {
+	if !h.breaker.allow(time.Now()) {
+		schedulerCounter.WithLabelValues(h.GetName(), "model_circuit_open").Inc()
+		return
+	}
+	span, finish := h.startSpan(hotSpanModelRequest)
+	defer finish()
+	setSpanTag(span, hotSpanTagRegion, key.regionID)
+	setSpanTag(span, hotSpanTagSrcStore, key.srcStoreID)
+	setSpanTag(span, hotSpanTagDestStore, destStoreID)
+	body, reqURL, breaker, client, predictions := modelRequestBody(ms), h.modelURL, h.breaker, h.client, h.predictions
+	predictions.ask(key, now)
+	queued := h.pool.submit(func() {
+		prediction, err := httpClient(client, reqURL, "POST", body)
+		if err == errModelDisabled {
+			return
+		}
+		breaker.record(time.Now(), err == nil)
+		predictions.answer(key, now, prediction)
+	})
+	if queued {
+		setSpanTag(span, hotSpanTagOutcome, "queued")
+	} else {
+		breaker.cancelProbe()
+		predictions.forget(key)
+		setSpanTag(span, hotSpanTagOutcome, "dropped")
+	}
+}
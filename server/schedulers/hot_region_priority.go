@@ -0,0 +1,81 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"sort"
+
+	"github.com/pingcap/pd/server/schedule"
+)
+
+// ScoredOperator is an operator of the hot region scheduler with the score
+// of its step given by the model service, so that the caller can admit the
+// most promising operators first.
+type ScoredOperator struct {
+	Op *schedule.Operator
+	// ModelScore is the probability of the step predicted by the model
+	// service. It is 0 if the model is not asked, which is the case unless
+	// the model mode is active, or if it suggests another step.
+	ModelScore float64
+	// Strategy is the reason why the heuristics choose the destination.
+	Strategy string
+}
+
+// decisionScore is the model score of the step of the decision.
+func decisionScore(record hotDecisionRecord) float64 {
+	p := record.Prediction
+	if p == nil || p.SrcStoreID != record.SourceStoreID || p.DestStoreID != record.DestStoreID {
+		return 0
+	}
+	return p.Probability
+}
+
+// dispatchScored is dispatch with the operators annotated by the score and
+// the strategy of the decision creating them, which is the last one made in
+// the dispatch. The dispatch is a preview if preview is set, see DryRun.
+func (h *balanceHotRegionsScheduler) dispatchScored(typ BalanceType, cluster schedule.Cluster, preview bool) []ScoredOperator {
+	h.Lock()
+	defer h.Unlock()
+	h.preview = preview
+	defer func() { h.preview = false }()
+	ops := h.dispatchLocked(typ, cluster)
+	if len(ops) == 0 {
+		return nil
+	}
+	scored := make([]ScoredOperator, 0, len(ops))
+	for _, op := range ops {
+		scored = append(scored, ScoredOperator{Op: op, ModelScore: h.lastScore, Strategy: h.lastStrategy})
+	}
+	return scored
+}
+
+func scoredOperators(scored []ScoredOperator) []*schedule.Operator {
+	if len(scored) == 0 {
+		return nil
+	}
+	ops := make([]*schedule.Operator, 0, len(scored))
+	for _, s := range scored {
+		ops = append(ops, s.Op)
+	}
+	return ops
+}
+
+// ScheduleWithPriority is Schedule with the operators annotated by the
+// scores of the model service, sorted by the score from the highest, so the
+// caller can admit only the top ones.
+func (h *balanceHotRegionsScheduler) ScheduleWithPriority(cluster schedule.Cluster) []ScoredOperator {
+	scored := h.scheduleScored(cluster)
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].ModelScore > scored[j].ModelScore })
+	return scored
+}
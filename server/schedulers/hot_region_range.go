@@ -0,0 +1,87 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+	"github.com/pkg/errors"
+)
+
+// hotKeyRange is the key range the scheduler works on, [startKey, endKey).
+// An empty startKey or endKey means unbounded, so the zero value is the
+// whole keyspace.
+type hotKeyRange struct {
+	startKey []byte
+	endKey   []byte
+}
+
+// parseHexKey decodes the hex-encoded key of the name.
+func parseHexKey(name, key string) ([]byte, error) {
+	b, err := hex.DecodeString(key)
+	if err != nil {
+		return nil, errors.Errorf("invalid %s %q, it must be hex-encoded", name, key)
+	}
+	return b, nil
+}
+
+// validate checks the start key is before the end key.
+func (r hotKeyRange) validate() error {
+	if len(r.endKey) != 0 && bytes.Compare(r.startKey, r.endKey) >= 0 {
+		return errors.Errorf("invalid key range, start key %x must be less than end key %x", r.startKey, r.endKey)
+	}
+	return nil
+}
+
+// String implements fmt.Stringer, the keys are hex-encoded as in the API.
+func (r hotKeyRange) String() string {
+	return fmt.Sprintf("[%x, %x)", r.startKey, r.endKey)
+}
+
+func (r hotKeyRange) isWhole() bool {
+	return len(r.startKey) == 0 && len(r.endKey) == 0
+}
+
+// overlaps checks if the region overlaps the range, like the regions
+// collected by the scatter-range schedulers.
+func (r hotKeyRange) overlaps(region *core.RegionInfo) bool {
+	if len(r.endKey) != 0 && bytes.Compare(region.GetStartKey(), r.endKey) >= 0 {
+		return false
+	}
+	return len(region.GetEndKey()) == 0 || bytes.Compare(region.GetEndKey(), r.startKey) > 0
+}
+
+// filterKeyRange keeps the stats of the hot regions overlapping the key
+// range of the scheduler.
+func (h *balanceHotRegionsScheduler) filterKeyRange(items []*core.RegionStat, cluster schedule.Cluster) []*core.RegionStat {
+	if h.keyRange.isWhole() {
+		return items
+	}
+	filtered := make([]*core.RegionStat, 0, len(items))
+	for _, r := range items {
+		region := cluster.GetRegion(r.RegionID)
+		if region == nil || !h.keyRange.overlaps(region) {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	if skipped := len(items) - len(filtered); skipped > 0 {
+		schedulerCounter.WithLabelValues(h.GetName(), "skip_out_of_range").Add(float64(skipped))
+	}
+	return filtered
+}
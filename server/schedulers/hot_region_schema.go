@@ -0,0 +1,235 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultModelSchemaPath is the path of the model service advertising
+	// the schema version of the features it expects.
+	defaultModelSchemaPath = "/model/pd/schema"
+	// defaultModelFeatureSlots is the number of the candidate slots of the
+	// v2 features when the model service asks for them but
+	// "model-feature-slots" is not set.
+	defaultModelFeatureSlots = 8
+)
+
+// featureSchema is the schema version of the features sent to the model
+// service.
+type featureSchema int
+
+const (
+	// featureSchemaV1 describes the destination store only, indexed by its
+	// ID.
+	featureSchemaV1 featureSchema = iota + 1
+	// featureSchemaV2 is the fixed-length vector describing the candidate
+	// stores in slots.
+	featureSchemaV2
+)
+
+func (s featureSchema) String() string {
+	switch s {
+	case featureSchemaV1:
+		return "v1"
+	case featureSchemaV2:
+		return "v2"
+	}
+	return "unknown"
+}
+
+func parseFeatureSchema(s string) (featureSchema, error) {
+	switch s {
+	case "v1":
+		return featureSchemaV1, nil
+	case "v2":
+		return featureSchemaV2, nil
+	}
+	return 0, errors.Errorf("invalid model schema %q", s)
+}
+
+// modelSchemaResponse is the response of the schema path of the model service.
+type modelSchemaResponse struct {
+	Version *int `json:"version"`
+}
+
+// parseModelSchemaResponse returns the schema version advertised in the body.
+func parseModelSchemaResponse(body []byte) (featureSchema, error) {
+	var resp modelSchemaResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if resp.Version == nil {
+		return 0, errors.New("no schema version advertised")
+	}
+	schema := featureSchema(*resp.Version)
+	if schema != featureSchemaV1 && schema != featureSchemaV2 {
+		return 0, errors.Errorf("unsupported schema version %d", *resp.Version)
+	}
+	return schema, nil
+}
+
+// modelSchemaURL returns the URL of the schema path on the host of the model
+// service.
+func modelSchemaURL(modelURL, schemaPath string) (string, error) {
+	u, err := url.Parse(modelURL)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	u.Path, u.RawQuery = schemaPath, ""
+	return u.String(), nil
+}
+
+// configuredSchema is the schema passed at registration, which is used until
+// the model service advertises one. By default it is v2 if
+// "model-feature-slots" is set, v1 otherwise.
+func (h *balanceHotRegionsScheduler) configuredSchema() featureSchema {
+	if h.modelSchema != 0 {
+		return h.modelSchema
+	}
+	if h.featureSlots > 0 {
+		return featureSchemaV2
+	}
+	return featureSchemaV1
+}
+
+// effectiveSchema is the schema of the features sent to the model service.
+func (h *balanceHotRegionsScheduler) effectiveSchema() featureSchema {
+	if h.negotiatedSchema != 0 {
+		return h.negotiatedSchema
+	}
+	return h.configuredSchema()
+}
+
+// effectiveFeatureSlots is the number of the candidate slots of the v2
+// features.
+func (h *balanceHotRegionsScheduler) effectiveFeatureSlots() int {
+	if h.featureSlots > 0 {
+		return h.featureSlots
+	}
+	return defaultModelFeatureSlots
+}
+
+// schemaNegotiation is a negotiation in flight, the worker asks the model
+// service and the dispatch applies the result once it is done.
+type schemaNegotiation struct {
+	sync.Mutex
+	done   bool
+	schema featureSchema
+	err    error
+}
+
+func (n *schemaNegotiation) finish(schema featureSchema, err error) {
+	n.Lock()
+	defer n.Unlock()
+	n.done, n.schema, n.err = true, schema, err
+}
+
+func (n *schemaNegotiation) result() (done bool, schema featureSchema, err error) {
+	n.Lock()
+	defer n.Unlock()
+	return n.done, n.schema, n.err
+}
+
+// updateModelSchema applies the result of the negotiation in flight, and
+// starts a new one when the model URL is changed, including the first
+// dispatch with a model service, or after the circuit breaker recovers. The
+// negotiation is sent by the workers, so the dispatch never waits for it.
+func (h *balanceHotRegionsScheduler) updateModelSchema() {
+	if n := h.schemaNegotiation; n != nil {
+		if done, schema, err := n.result(); done {
+			h.schemaNegotiation = nil
+			h.applyModelSchema(schema, err)
+		}
+	}
+	if recoveries := h.breaker.getRecoveries(); recoveries != h.breakerRecoveries {
+		h.breakerRecoveries, h.schemaDue = recoveries, true
+	}
+	if !h.schemaDue || h.modelURL == "" || h.modelMode == modelModeOff || h.hypothetical() {
+		return
+	}
+	schemaURL, err := modelSchemaURL(h.modelURL, h.modelSchemaPath)
+	if err != nil {
+		h.schemaDue = false
+		h.applyModelSchema(0, err)
+		return
+	}
+	n, client := &schemaNegotiation{}, h.client
+	if !h.pool.submit(func() {
+		n.finish(negotiateModelSchema(client, schemaURL))
+	}) {
+		// Retry by the next dispatch.
+		return
+	}
+	h.schemaDue, h.schemaNegotiation = false, n
+}
+
+// applyModelSchema follows the schema advertised by the model service, or
+// falls back to the configured one if the negotiation failed.
+func (h *balanceHotRegionsScheduler) applyModelSchema(schema featureSchema, err error) {
+	if err != nil {
+		hotModelSchemaFallbackCounter.Inc()
+		log.Warnf("[%s] failed to negotiate the feature schema with the model service, use %s: %v",
+			h.GetName(), h.configuredSchema(), err)
+		h.negotiatedSchema = 0
+		return
+	}
+	if schema != h.negotiatedSchema {
+		log.Infof("[%s] the model service expects the features of schema %s", h.GetName(), schema)
+	}
+	h.negotiatedSchema = schema
+}
+
+// negotiateModelSchema asks the model service for the schema version it
+// expects.
+func negotiateModelSchema(client *modelClient, schemaURL string) (featureSchema, error) {
+	body, err := modelRequest(client, schemaURL, "GET", "")
+	if err != nil {
+		return 0, err
+	}
+	return parseModelSchemaResponse(body)
+}
+
+// schemaFeature is the feature telling the model service the negotiated
+// schema version, nil if the schema is not negotiated.
+func (h *balanceHotRegionsScheduler) schemaFeature() []Feature {
+	if h.negotiatedSchema == 0 {
+		return nil
+	}
+	return []Feature{hotFeatures.Build(featureSchemaVersion, 0, strconv.Itoa(int(h.negotiatedSchema)))}
+}
+
+// modelSchemaStatus is the schema of the features sent to the model service.
+type modelSchemaStatus struct {
+	Version string `json:"version"`
+	// Negotiated is false if the configured schema is used, because the
+	// model service doesn't advertise one or it is not asked yet.
+	Negotiated bool `json:"negotiated"`
+}
+
+func (h *balanceHotRegionsScheduler) schemaStatus() modelSchemaStatus {
+	h.RLock()
+	defer h.RUnlock()
+	return modelSchemaStatus{
+		Version:    h.effectiveSchema().String(),
+		Negotiated: h.negotiatedSchema != 0,
+	}
+}
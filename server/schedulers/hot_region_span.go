@@ -0,0 +1,63 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"github.com/opentracing/opentracing-go"
+)
+
+// The span operation names and tags of balanceHotRegionsScheduler.
+const (
+	hotSpanDispatch      = "hot-region.dispatch"
+	hotSpanCalcScore     = "hot-region.calc-score"
+	hotSpanBalancePeer   = "hot-region.balance-peer"
+	hotSpanBalanceLeader = "hot-region.balance-leader"
+	hotSpanModelRequest  = "hot-region.model-request"
+
+	hotSpanTagBalanceType = "balance_type"
+	hotSpanTagKind        = "kind"
+	hotSpanTagRegion      = "region_id"
+	hotSpanTagSrcStore    = "src_store"
+	hotSpanTagDestStore   = "dest_store"
+	hotSpanTagRelaxed     = "relaxed"
+	hotSpanTagOutcome     = "outcome"
+)
+
+func finishNoSpan() {}
+
+// startSpan starts a span as the child of the current span and makes it the
+// current one, the returned function finishes the span and restores the
+// parent. The span is nil if tracing is disabled.
+func (h *balanceHotRegionsScheduler) startSpan(operationName string) (opentracing.Span, func()) {
+	if h.tracer == nil {
+		return nil, finishNoSpan
+	}
+	parent := h.span
+	var opts []opentracing.StartSpanOption
+	if parent != nil {
+		opts = append(opts, opentracing.ChildOf(parent.Context()))
+	}
+	span := h.tracer.StartSpan(operationName, opts...)
+	h.span = span
+	return span, func() {
+		span.Finish()
+		h.span = parent
+	}
+}
+
+func setSpanTag(span opentracing.Span, key string, value interface{}) {
+	if span != nil {
+		span.SetTag(key, value)
+	}
+}
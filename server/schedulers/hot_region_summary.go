@@ -0,0 +1,115 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import "github.com/pingcap/pd/server/core"
+
+// RegionProvider provides the regions the hot statistics are placed by, it
+// is implemented by schedule.Cluster, and can be backed by a static map.
+type RegionProvider interface {
+	GetRegion(regionID uint64) *core.RegionInfo
+}
+
+// SummarizeHotRegions aggregates the hot region statistics per store the same
+// way as the hot region scheduler, without running it. The regions with a
+// hot degree below threshold, unknown to regions, reported before a split or
+// with down or pending peers are skipped. kind decides whether a region counts toward its leader store
+// or all the stores of its peers. The median flow of the rolling statistics
+// is used as the region flow, or FlowBytes if Stats is nil, e.g. the items
+// are decoded from a dump.
+func SummarizeHotRegions(items []*core.RegionStat, regions RegionProvider, kind core.ResourceKind, threshold int) core.StoreHotRegionsStat {
+	stats, _, _ := summarizeHotRegions(items, regions, kind, threshold, 0)
+	return stats
+}
+
+// summarizeHotRegions is SummarizeHotRegions which also keeps the regions
+// below minRegionFlowBytes out of the candidates, they only count toward the
+// store totals. It returns the number of the stats reported before a split
+// and the number of the regions with down or pending peers.
+func summarizeHotRegions(items []*core.RegionStat, regions RegionProvider, kind core.ResourceKind, threshold int, minRegionFlowBytes uint64) (stats core.StoreHotRegionsStat, staleCount, unhealthyCount int) {
+	stats = make(core.StoreHotRegionsStat)
+	for _, r := range items {
+		if r.HotDegree < threshold {
+			continue
+		}
+
+		regionInfo := regions.GetRegion(r.RegionID)
+		if regionInfo == nil {
+			continue
+		}
+		// After a split, the stat reported before the split lingers for one
+		// cycle while the new regions report their own flow. RegionStat does
+		// not carry the key range, but the region keeping the ID has a newer
+		// version, so drop the stale stat to avoid double-counting the flow.
+		if r.Version < regionInfo.GetRegionEpoch().GetVersion() {
+			staleCount++
+			continue
+		}
+		// The regions with down or pending peers are never moved, so their
+		// flow is not movable load of the stores.
+		if len(regionInfo.GetDownPeers()) != 0 || len(regionInfo.GetPendingPeers()) != 0 {
+			unhealthyCount++
+			continue
+		}
+
+		var storeIDs []uint64
+		switch kind {
+		case core.RegionKind:
+			for id := range regionInfo.GetStoreIds() {
+				storeIDs = append(storeIDs, id)
+			}
+		case core.LeaderKind:
+			storeIDs = append(storeIDs, regionInfo.GetLeader().GetStoreId())
+		}
+
+		flowBytes, flowBytesP99 := r.FlowBytes, uint64(0)
+		if r.Stats != nil {
+			flowBytes, flowBytesP99 = uint64(r.Stats.Median()), r.Stats.Percentile(99)
+		}
+		for _, storeID := range storeIDs {
+			storeStat, ok := stats[storeID]
+			if !ok {
+				storeStat = &core.HotRegionsStat{
+					RegionsStat: make(core.RegionsStat, 0, storeHotRegionsDefaultLen),
+				}
+				stats[storeID] = storeStat
+			}
+
+			s := core.RegionStat{
+				RegionID:             r.RegionID,
+				FlowBytes:            flowBytes,
+				FlowBytesP99:         flowBytesP99,
+				FlowKeys:             r.FlowKeys,
+				HotDegree:            r.HotDegree,
+				LastUpdateTime:       r.LastUpdateTime,
+				StoreID:              storeID,
+				AntiCount:            r.AntiCount,
+				Version:              r.Version,
+				CoprocessorFlowBytes: r.CoprocessorFlowBytes,
+				GetFlowBytes:         r.GetFlowBytes,
+				SplitCandidate:       isScanHot(r),
+			}
+			storeStat.TotalFlowBytes += r.FlowBytes
+			storeStat.TotalFlowKeys += r.FlowKeys
+			storeStat.RegionsCount++
+			// The regions carrying little flow are not worth an operator,
+			// only the movable regions are candidates of the selection.
+			if s.FlowBytes < minRegionFlowBytes {
+				continue
+			}
+			storeStat.RegionsStat = append(storeStat.RegionsStat, s)
+		}
+	}
+	return stats, staleCount, unhealthyCount
+}
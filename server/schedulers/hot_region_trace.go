@@ -0,0 +1,159 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"math/rand"
+
+	"github.com/pingcap/pd/server/core"
+	"github.com/pingcap/pd/server/schedule"
+	"github.com/pkg/errors"
+)
+
+// HotRegionSnapshot is a synthetic cluster to trace the decision of the hot
+// region scheduler on.
+type HotRegionSnapshot struct {
+	// Kind is the kind of the decision, "leader" or "peer".
+	Kind    string              `json:"kind"`
+	Stores  []HotSnapshotStore  `json:"stores"`
+	Regions []HotSnapshotRegion `json:"regions"`
+}
+
+// HotSnapshotStore is a store of HotRegionSnapshot.
+type HotSnapshotStore struct {
+	ID     uint64            `json:"id"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// HotSnapshotRegion is a hot region of HotRegionSnapshot.
+type HotSnapshotRegion struct {
+	ID        uint64   `json:"id"`
+	Leader    uint64   `json:"leader"`
+	Followers []uint64 `json:"followers"`
+	FlowBytes uint64   `json:"flow_bytes"`
+	HotDegree int      `json:"hot_degree"`
+}
+
+// HotDecisionTrace is the full trace of a decision of the hot region scheduler.
+type HotDecisionTrace struct {
+	Kind          string                   `json:"kind"`
+	Stats         core.StoreHotRegionsStat `json:"stats"`
+	SourceStoreID uint64                   `json:"source_store_id"`
+	// Regions are the evaluated regions of the source store in order.
+	Regions     []HotRegionEvaluation `json:"regions"`
+	RegionID    uint64                `json:"region_id"`
+	DestStoreID uint64                `json:"dest_store_id"`
+}
+
+// HotRegionEvaluation records the evaluations of the candidate stores when
+// trying to move a region.
+type HotRegionEvaluation struct {
+	RegionID   uint64                   `json:"region_id"`
+	Candidates []HotCandidateEvaluation `json:"candidates"`
+}
+
+// HotCandidateEvaluation is the result of evaluating a candidate store.
+type HotCandidateEvaluation struct {
+	StoreID uint64 `json:"store_id"`
+	Result  string `json:"result"`
+}
+
+// TraceHotRegionDecision makes a decision on the snapshot with the heuristics
+// of the hot region scheduler and returns the trace. The real cluster and the
+// model service are never touched.
+func TraceHotRegionDecision(snapshot *HotRegionSnapshot) (*HotDecisionTrace, error) {
+	var kind core.ResourceKind
+	switch snapshot.Kind {
+	case "leader":
+		kind = core.LeaderKind
+	case "peer":
+		kind = core.RegionKind
+	default:
+		return nil, errors.Errorf("unknown decision kind %q", snapshot.Kind)
+	}
+
+	opt := schedule.NewMockSchedulerOptions()
+	opt.HotRegionLowThreshold = 0
+	cluster := schedule.NewMockCluster(opt)
+	for _, s := range snapshot.Stores {
+		cluster.AddLabelsStore(s.ID, 0, s.Labels)
+	}
+	items := make([]*core.RegionStat, 0, len(snapshot.Regions))
+	for _, r := range snapshot.Regions {
+		if cluster.GetStore(r.Leader) == nil {
+			return nil, errors.Errorf("leader store %d of region %d not found", r.Leader, r.ID)
+		}
+		cluster.AddLeaderRegion(r.ID, r.Leader, r.Followers...)
+		stats := core.NewRollingStats(1)
+		stats.Add(float64(r.FlowBytes))
+		items = append(items, &core.RegionStat{
+			RegionID:  r.ID,
+			FlowBytes: r.FlowBytes,
+			HotDegree: r.HotDegree,
+			Stats:     stats,
+		})
+	}
+
+	h := newBalanceHotRegionsScheduler(schedule.NewOperatorController(nil, nil))
+	// The regions are evaluated in a fixed order for the same snapshot.
+	h.setRandSource(rand.NewSource(0))
+	trace := &HotDecisionTrace{Kind: snapshot.Kind}
+	h.trace = trace
+
+	trace.Stats = h.calcScore(items, cluster, kind)
+	trace.SourceStoreID = h.selectSrcStore(trace.Stats, nil)
+	if trace.SourceStoreID == 0 {
+		return trace, nil
+	}
+	switch kind {
+	case core.LeaderKind:
+		if srcRegion, destPeer := h.balanceLeaderFrom(cluster, trace.SourceStoreID, trace.Stats, false); srcRegion != nil {
+			trace.RegionID, trace.DestStoreID = srcRegion.GetID(), destPeer.GetStoreId()
+		}
+	case core.RegionKind:
+		if srcRegion, _, destPeer := h.balancePeerFrom(cluster, trace.SourceStoreID, trace.Stats, false); srcRegion != nil {
+			trace.RegionID, trace.DestStoreID = srcRegion.GetID(), destPeer.GetStoreId()
+		}
+	}
+	return trace, nil
+}
+
+// filterTarget checks if the store can pass all the filters as target store.
+// The rejections are not counted when tracing.
+func (h *balanceHotRegionsScheduler) filterTarget(cluster schedule.Cluster, store *core.StoreInfo, filters []schedule.Filter) bool {
+	if h.trace == nil {
+		return schedule.FilterTargetCounted(cluster, store, filters, h.GetName(), h.filterStats)
+	}
+	for _, filter := range filters {
+		if filter.FilterTarget(cluster, store) {
+			h.traceCandidate(store.GetId(), "filtered by "+filter.Type())
+			return true
+		}
+	}
+	return false
+}
+
+func (h *balanceHotRegionsScheduler) traceRegion(regionID uint64) {
+	if h.trace != nil {
+		h.trace.Regions = append(h.trace.Regions, HotRegionEvaluation{RegionID: regionID})
+	}
+}
+
+func (h *balanceHotRegionsScheduler) traceCandidate(storeID uint64, result string) {
+	if h.trace == nil || len(h.trace.Regions) == 0 {
+		return
+	}
+	last := &h.trace.Regions[len(h.trace.Regions)-1]
+	last.Candidates = append(last.Candidates, HotCandidateEvaluation{StoreID: storeID, Result: result})
+}
@@ -0,0 +1,97 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schedulers
+
+import (
+	"github.com/coreos/go-semver/semver"
+	"github.com/pingcap/pd/server/schedule"
+	log "github.com/sirupsen/logrus"
+)
+
+// The optional features of the scheduler depending on the capabilities of
+// TiKV.
+const (
+	// hotFeatureKeysFlow is balancing on the flow keys, by the hot-keys
+	// balance type or keys-weight, which requires the flow keys reported by
+	// the region heartbeats.
+	hotFeatureKeysFlow = "keys-flow"
+	// hotFeatureFollowerRead is advising the follower read instead of moving
+	// the hot read regions.
+	hotFeatureFollowerRead = "follower-read"
+)
+
+// hotFeatureGate disables a feature if the cluster version is lower than
+// minVersion, enabled reports whether the feature is enabled by the config.
+type hotFeatureGate struct {
+	name       string
+	minVersion semver.Version
+	enabled    func(h *balanceHotRegionsScheduler) bool
+}
+
+var hotFeatureGates = []hotFeatureGate{
+	{
+		name:       hotFeatureKeysFlow,
+		minVersion: semver.Version{Major: 2, Minor: 1},
+		enabled: func(h *balanceHotRegionsScheduler) bool {
+			return h.keysWeight > 0 || containsBalanceType(h.types, hotKeysRegionBalance)
+		},
+	},
+	{
+		name:       hotFeatureFollowerRead,
+		minVersion: semver.Version{Major: 3},
+		enabled: func(h *balanceHotRegionsScheduler) bool {
+			return h.followerReadFraction > 0
+		},
+	},
+}
+
+func containsBalanceType(types []BalanceType, typ BalanceType) bool {
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// updateFeatureGates disables the enabled features unsupported by the
+// cluster version for the current dispatch, the config is kept so they are
+// enabled again once the cluster is upgraded. Each feature is warned once.
+func (h *balanceHotRegionsScheduler) updateFeatureGates(cluster schedule.Cluster) {
+	version := cluster.GetClusterVersion()
+	h.clusterVersion = version
+	h.disabledByVersion = nil
+	for _, gate := range hotFeatureGates {
+		if !gate.enabled(h) || !version.LessThan(gate.minVersion) {
+			continue
+		}
+		h.disabledByVersion = append(h.disabledByVersion, gate.name)
+		if _, ok := h.versionWarned[gate.name]; !ok {
+			h.versionWarned[gate.name] = struct{}{}
+			log.Warnf("[%s] %s is disabled, it requires cluster version %s but the cluster version is %s",
+				h.GetName(), gate.name, gate.minVersion, version)
+		}
+	}
+}
+
+// disabledByVersionGate returns true if the feature is disabled by the cluster
+// version in the current dispatch.
+func (h *balanceHotRegionsScheduler) disabledByVersionGate(name string) bool {
+	for _, disabled := range h.disabledByVersion {
+		if disabled == name {
+			return true
+		}
+	}
+	return false
+}
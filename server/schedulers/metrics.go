@@ -47,9 +47,144 @@ var balanceRegionCounter = prometheus.NewCounterVec(
 		Help:      "Counter of balance region scheduler.",
 	}, []string{"type", "store"})
 
+var hotModelSuggestionRank = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "hot_model_suggestion_rank",
+		Help:      "Bucketed histogram of the rank of the model's suggestion in the hot region candidates, 0 means not a candidate.",
+		Buckets:   prometheus.LinearBuckets(0, 1, 10),
+	})
+
+var hotModelParseErrorCounter = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "model_parse_error",
+		Help:      "Counter of the malformed responses of the hot region model service.",
+	})
+
+var hotModelPredictionCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "hot_model_prediction",
+		Help:      "Counter of the hot region model predictions matching the heuristic decisions or not.",
+	}, []string{"mode", "result"})
+
+var hotSelectionGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "hot_selection",
+		Help:      "The hot statistics of the source and destination stores when they are selected by the hot region scheduler.",
+	}, []string{"store", "type", "name"})
+
+// hotLatencyBuckets are the buckets of the hot region scheduler latencies, in
+// seconds, from 1ms to 500ms.
+var hotLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5}
+
+var hotScheduleLatency = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "hot_schedule_duration_seconds",
+		Help:      "Bucketed histogram of the duration of the schedules of the hot region scheduler.",
+		Buckets:   hotLatencyBuckets,
+	}, []string{"type"})
+
+var hotScheduleStageLatency = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "hot_schedule_stage_duration_seconds",
+		Help:      "Bucketed histogram of the duration of the stages of the hot region scheduler.",
+		Buckets:   hotLatencyBuckets,
+	}, []string{"type", "stage"})
+
+var hotModelCircuitOpen = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "model_circuit_open",
+		Help:      "Whether the circuit breaker of the hot region model service is open (1) or closed (0), the half-open breaker is open.",
+	})
+
+var hotModelBatchSize = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "hot_model_batch_size",
+		Help:      "Bucketed histogram of the number of the updates in a batch sent to the hot region model service.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 8),
+	})
+
+var hotModelFlushLatency = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "hot_model_flush_duration_seconds",
+		Help:      "Bucketed histogram of the duration of sending a batch of updates to the hot region model service.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 12),
+	})
+
+var hotModelDisabled = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "model_disabled",
+		Help:      "Whether the kill switch of the hot region model service is on (1) or off (0).",
+	})
+
+var hotModelRefusedCounter = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "model_refused",
+		Help:      "Counter of the requests to the hot region model service refused by the kill switch.",
+	})
+
+var hotModelSchemaFallbackCounter = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "model_schema_fallback",
+		Help:      "Counter of the failed feature schema negotiations with the hot region model service, the configured schema is used then.",
+	})
+
+var hotMemoryGauge = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "hot_memory_bytes",
+		Help:      "The estimated memory of the auxiliary structures of the hot region scheduler.",
+	}, []string{"structure"})
+
+var hotMemoryEvictedCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pd",
+		Subsystem: "scheduler",
+		Name:      "hot_memory_evicted",
+		Help:      "Counter of the entries of the auxiliary structures of the hot region scheduler evicted by the memory budget.",
+	}, []string{"structure"})
+
 func init() {
 	prometheus.MustRegister(schedulerCounter)
 	prometheus.MustRegister(schedulerStatus)
 	prometheus.MustRegister(balanceLeaderCounter)
 	prometheus.MustRegister(balanceRegionCounter)
+	prometheus.MustRegister(hotModelSuggestionRank)
+	prometheus.MustRegister(hotModelParseErrorCounter)
+	prometheus.MustRegister(hotModelPredictionCounter)
+	prometheus.MustRegister(hotSelectionGauge)
+	prometheus.MustRegister(hotScheduleLatency)
+	prometheus.MustRegister(hotScheduleStageLatency)
+	prometheus.MustRegister(hotModelCircuitOpen)
+	prometheus.MustRegister(hotModelBatchSize)
+	prometheus.MustRegister(hotModelFlushLatency)
+	prometheus.MustRegister(hotModelDisabled)
+	prometheus.MustRegister(hotModelRefusedCounter)
+	prometheus.MustRegister(hotModelSchemaFallbackCounter)
+	prometheus.MustRegister(hotMemoryGauge)
+	prometheus.MustRegister(hotMemoryEvictedCounter)
 }
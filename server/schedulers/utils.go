@@ -14,6 +14,7 @@
 package schedulers
 
 import (
+	"math"
 	"time"
 
 	"github.com/montanaflynn/stats"
@@ -43,6 +44,32 @@ func minDuration(a, b time.Duration) time.Duration {
 	return b
 }
 
+// safeDiv returns a/b, or 0 if the quotient is not finite, e.g. b is 0 as
+// reported by a freshly added store.
+func safeDiv(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return finiteOrZero(a / b)
+}
+
+func finiteOrZero(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	return v
+}
+
+// sanitizeFloat replaces NaN and Inf with 0 before the value is exported, since
+// neither JSON nor the metrics accept them, and counts the replacement.
+func sanitizeFloat(scheduler string, v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		schedulerCounter.WithLabelValues(scheduler, "non_finite_value").Inc()
+		return 0
+	}
+	return v
+}
+
 func shouldBalance(cluster schedule.Cluster, source, target *core.StoreInfo, region *core.RegionInfo, kind core.ResourceKind, opInfluence schedule.OpInfluence) bool {
 	// The reason we use max(regionSize, averageRegionSize) to check is:
 	// 1. prevent moving small regions between stores with close scores, leading to unnecessary balance.
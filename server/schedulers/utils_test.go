@@ -14,6 +14,7 @@
 package schedulers
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -45,3 +46,14 @@ func (s *testMinMaxSuite) TestMinDuration(c *C) {
 	c.Assert(minDuration(time.Second, time.Minute), Equals, time.Second)
 	c.Assert(minDuration(time.Second, time.Second), Equals, time.Second)
 }
+
+func (s *testMinMaxSuite) TestSafeDiv(c *C) {
+	c.Assert(safeDiv(3, 2), Equals, 1.5)
+	c.Assert(safeDiv(1, 0), Equals, 0.0)
+	c.Assert(safeDiv(0, 0), Equals, 0.0)
+	c.Assert(safeDiv(math.Inf(1), 1), Equals, 0.0)
+	c.Assert(safeDiv(math.NaN(), 1), Equals, 0.0)
+	c.Assert(sanitizeFloat("test", math.NaN()), Equals, 0.0)
+	c.Assert(sanitizeFloat("test", math.Inf(-1)), Equals, 0.0)
+	c.Assert(sanitizeFloat("test", 2.5), Equals, 2.5)
+}
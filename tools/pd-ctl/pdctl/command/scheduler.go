@@ -22,7 +22,8 @@ import (
 )
 
 var (
-	schedulersPrefix = "pd/api/v1/schedulers"
+	schedulersPrefix      = "pd/api/v1/schedulers"
+	schedulerConfigPrefix = "pd/api/v1/scheduler-config"
 )
 
 // NewSchedulerCommand returns a scheduler command.
@@ -34,6 +35,7 @@ func NewSchedulerCommand() *cobra.Command {
 	c.AddCommand(NewShowSchedulerCommand())
 	c.AddCommand(NewAddSchedulerCommand())
 	c.AddCommand(NewRemoveSchedulerCommand())
+	c.AddCommand(NewConfigSchedulerCommand())
 	return c
 }
 
@@ -285,3 +287,34 @@ func removeSchedulerCommandFunc(cmd *cobra.Command, args []string) {
 		return
 	}
 }
+
+// NewConfigSchedulerCommand returns a command to show or set the config of a scheduler.
+func NewConfigSchedulerCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "config <scheduler> [set <option> <value>]",
+		Short: "show or set the config of a scheduler",
+		Run:   configSchedulerCommandFunc,
+	}
+	return c
+}
+
+func configSchedulerCommandFunc(cmd *cobra.Command, args []string) {
+	switch {
+	case len(args) == 1:
+		r, err := doRequest(cmd, schedulerConfigPrefix+"/"+args[0], http.MethodGet)
+		if err != nil {
+			cmd.Println(err)
+			return
+		}
+		cmd.Println(r)
+	case len(args) == 4 && args[1] == "set":
+		err := postConfigDataWithPath(cmd, args[2], args[3], schedulerConfigPrefix+"/"+args[0])
+		if err != nil {
+			cmd.Printf("Failed to set the config of %s: %s\n", args[0], err)
+			return
+		}
+		cmd.Println("Success!")
+	default:
+		cmd.Println(cmd.UsageString())
+	}
+}